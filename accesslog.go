@@ -0,0 +1,325 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AccessLogFormat is the format of access log records.
+type AccessLogFormat string
+
+const (
+	// AccessLogCommon is the Common Log Format.
+	AccessLogCommon AccessLogFormat = "common"
+	// AccessLogCombined is the Combined Log Format, which adds referer and user agent to the Common Log Format.
+	AccessLogCombined AccessLogFormat = "combined"
+	// AccessLogJSON writes each record as a JSON object in a line.
+	AccessLogJSON AccessLogFormat = "json"
+	// AccessLogSlog logs each record with attributes through a *slog.Logger.
+	AccessLogSlog AccessLogFormat = "slog"
+)
+
+// Fields of access log records in JSON and slog formats.
+const (
+	AccessLogFieldTime       = "time"
+	AccessLogFieldRemoteAddr = "remote_addr"
+	AccessLogFieldUser       = "user"
+	AccessLogFieldMethod     = "method"
+	AccessLogFieldURI        = "uri"
+	AccessLogFieldProto      = "proto"
+	AccessLogFieldStatus     = "status"
+	AccessLogFieldBytes      = "bytes"
+	AccessLogFieldLatency    = "latency_ms"
+	AccessLogFieldRoute      = "route"
+	AccessLogFieldRequestID  = "request_id"
+	AccessLogFieldReferer    = "referer"
+	AccessLogFieldUserAgent  = "user_agent"
+)
+
+var defaultAccessLogFields = []string{
+	AccessLogFieldTime,
+	AccessLogFieldRemoteAddr,
+	AccessLogFieldUser,
+	AccessLogFieldMethod,
+	AccessLogFieldURI,
+	AccessLogFieldProto,
+	AccessLogFieldStatus,
+	AccessLogFieldBytes,
+	AccessLogFieldLatency,
+	AccessLogFieldRoute,
+	AccessLogFieldRequestID,
+	AccessLogFieldReferer,
+	AccessLogFieldUserAgent,
+}
+
+// AccessLogOptions represents a struct for specifying configuration options for the AccessLog middleware.
+type AccessLogOptions struct {
+	// Format of records. Default is AccessLogCombined.
+	Format AccessLogFormat
+	// Output to write records to in text formats. Default is the output of the mapped *log.Logger.
+	Output io.Writer
+	// Logger to log records with in AccessLogSlog format. Default is slog.Default().
+	Logger *slog.Logger
+	// Fields to include in JSON and slog formats, in that order. Default is all fields.
+	Fields []string
+	// SampleRate is the fraction of requests to log, between 0 and 1. Default is 1.
+	// Requests that caused server errors are always logged.
+	SampleRate float64
+	// Skip returns true if the request should not be logged, e.g. health checks.
+	Skip func(ctx *Context) bool
+	// RequestIDHeader is the request header that carries the request ID when the RequestID middleware
+	// is not used. Default is "X-Request-ID".
+	RequestIDHeader string
+}
+
+func prepareAccessLogOptions(options []AccessLogOptions) AccessLogOptions {
+	var opt AccessLogOptions
+	if len(options) > 0 {
+		opt = options[0]
+	}
+
+	// Defaults.
+	if len(opt.Format) == 0 {
+		opt.Format = AccessLogCombined
+	}
+	if len(opt.Fields) == 0 {
+		opt.Fields = defaultAccessLogFields
+	}
+	if opt.SampleRate <= 0 || opt.SampleRate > 1 {
+		opt.SampleRate = 1
+	}
+	if len(opt.RequestIDHeader) == 0 {
+		opt.RequestIDHeader = DefaultRequestIDHeader
+	}
+
+	for _, field := range opt.Fields {
+		if (&accessRecord{}).value(field) == nil {
+			panic("unknown access log field: " + field)
+		}
+	}
+
+	switch opt.Format {
+	case AccessLogCommon, AccessLogCombined, AccessLogJSON:
+	case AccessLogSlog:
+		if opt.Logger == nil {
+			opt.Logger = slog.Default()
+		}
+	default:
+		panic("unknown access log format: " + string(opt.Format))
+	}
+	return opt
+}
+
+// accessRecord is the information of a request and its response to log.
+type accessRecord struct {
+	time       time.Time
+	remoteAddr string
+	user       string
+	method     string
+	uri        string
+	proto      string
+	status     int
+	bytes      int
+	latency    time.Duration
+	route      string
+	requestID  string
+	referer    string
+	userAgent  string
+}
+
+func (r *accessRecord) value(field string) interface{} {
+	switch field {
+	case AccessLogFieldTime:
+		return r.time
+	case AccessLogFieldRemoteAddr:
+		return r.remoteAddr
+	case AccessLogFieldUser:
+		return r.user
+	case AccessLogFieldMethod:
+		return r.method
+	case AccessLogFieldURI:
+		return r.uri
+	case AccessLogFieldProto:
+		return r.proto
+	case AccessLogFieldStatus:
+		return r.status
+	case AccessLogFieldBytes:
+		return r.bytes
+	case AccessLogFieldLatency:
+		return float64(r.latency) / float64(time.Millisecond)
+	case AccessLogFieldRoute:
+		return r.route
+	case AccessLogFieldRequestID:
+		return r.requestID
+	case AccessLogFieldReferer:
+		return r.referer
+	case AccessLogFieldUserAgent:
+		return r.userAgent
+	}
+	return nil
+}
+
+// orDash returns "-" for empty values as Common Log Format does.
+func orDash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
+
+// writeCommon writes the record in Common Log Format, with referer and user agent if combined.
+func (r *accessRecord) writeCommon(buf *bytes.Buffer, combined bool) {
+	buf.WriteString(orDash(r.remoteAddr))
+	buf.WriteString(" - ")
+	buf.WriteString(orDash(r.user))
+	buf.WriteString(" [")
+	buf.WriteString(r.time.Format("02/Jan/2006:15:04:05 -0700"))
+	buf.WriteString("] ")
+	buf.WriteString(strconv.Quote(r.method + " " + r.uri + " " + r.proto))
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(r.status))
+	buf.WriteByte(' ')
+	if r.bytes > 0 {
+		buf.WriteString(strconv.Itoa(r.bytes))
+	} else {
+		buf.WriteByte('-')
+	}
+	if combined {
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.Quote(orDash(r.referer)))
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.Quote(orDash(r.userAgent)))
+	}
+	buf.WriteByte('\n')
+}
+
+// writeJSON writes given fields of the record as a JSON object in a line.
+func (r *accessRecord) writeJSON(buf *bytes.Buffer, fields []string) error {
+	buf.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(field)
+		buf.Write(key)
+		buf.WriteByte(':')
+		p, err := json.Marshal(r.value(field))
+		if err != nil {
+			return err
+		}
+		buf.Write(p)
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+// attrs returns given fields of the record as slog attributes.
+func (r *accessRecord) attrs(fields []string) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		attrs = append(attrs, slog.Any(field, r.value(field)))
+	}
+	return attrs
+}
+
+// AccessLog returns a middleware handler that logs a record for every request after it is handled,
+// in Common Log Format, Combined Log Format, JSON or through log/slog.
+func AccessLog(options ...AccessLogOptions) Handler {
+	opt := prepareAccessLogOptions(options)
+	var lock sync.Mutex
+
+	return func(ctx *Context, logger *log.Logger) {
+		start := time.Now()
+		ctx.Next()
+
+		if opt.Skip != nil && opt.Skip(ctx) {
+			return
+		}
+		status := ctx.Resp.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status < http.StatusInternalServerError && opt.SampleRate < 1 && rand.Float64() >= opt.SampleRate {
+			return
+		}
+
+		user, _, _ := ctx.Req.BasicAuth()
+		r := &accessRecord{
+			time:       start,
+			remoteAddr: ctx.RemoteAddr(),
+			user:       user,
+			method:     ctx.Req.Method,
+			uri:        ctx.Req.RequestURI,
+			proto:      ctx.Req.Proto,
+			status:     status,
+			bytes:      ctx.Resp.Size(),
+			latency:    time.Since(start),
+			route:      ctx.RoutePattern(),
+			requestID:  ctx.RequestID(),
+			referer:    ctx.Req.Referer(),
+			userAgent:  ctx.Req.UserAgent(),
+		}
+		if len(r.uri) == 0 {
+			r.uri = ctx.Req.URL.RequestURI()
+		}
+		if len(r.requestID) == 0 {
+			r.requestID = ctx.Req.Header.Get(opt.RequestIDHeader)
+		}
+
+		if opt.Format == AccessLogSlog {
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			opt.Logger.LogAttrs(ctx.Req.Context(), level, "access", r.attrs(opt.Fields)...)
+			return
+		}
+
+		buf := bufpool.Get().(*bytes.Buffer)
+		defer func() {
+			buf.Reset()
+			bufpool.Put(buf)
+		}()
+		switch opt.Format {
+		case AccessLogCommon, AccessLogCombined:
+			r.writeCommon(buf, opt.Format == AccessLogCombined)
+		case AccessLogJSON:
+			if err := r.writeJSON(buf, opt.Fields); err != nil {
+				logger.Printf("AccessLog: %v", err)
+				return
+			}
+		}
+
+		w := opt.Output
+		if w == nil {
+			w = logger.Writer()
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		if _, err := buf.WriteTo(w); err != nil {
+			logger.Printf("AccessLog: %v", err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// shouldMatch asserts the actual value matches the regular expression.
+func shouldMatch(actual interface{}, expected ...interface{}) string {
+	if regexp.MustCompile(expected[0].(string)).MatchString(actual.(string)) {
+		return ""
+	}
+	return fmt.Sprintf("Expected %q to match %q (but it didn't)!", actual, expected[0])
+}
+
+func Test_AccessLog(t *testing.T) {
+	newMacaron := func(opt AccessLogOptions) *Macaron {
+		m := NewWithLogger(&bytes.Buffer{})
+		m.Use(AccessLog(opt))
+		m.Get("/user/:id", func() string { return "hello" })
+		m.Get("/panic", func(ctx *Context) { ctx.Resp.WriteHeader(http.StatusInternalServerError) })
+		m.Get("/health", func() {})
+		return m
+	}
+	request := func(m *Macaron, url string) {
+		req, err := http.NewRequest("GET", url, nil)
+		So(err, ShouldBeNil)
+		req.RequestURI = url
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.SetBasicAuth("frank", "secret")
+		req.Header.Set("Referer", "http://example.com/")
+		req.Header.Set("User-Agent", "Macaron")
+		req.Header.Set("X-Request-ID", "abc")
+		m.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	Convey("Log in Common Log Format", t, func() {
+		var buf bytes.Buffer
+		m := newMacaron(AccessLogOptions{Format: AccessLogCommon, Output: &buf})
+		request(m, "/user/1?a=b")
+		So(buf.String(), shouldMatch,
+			`^127\.0\.0\.1 - frank \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /user/1\?a=b HTTP/1\.1" 200 5\n$`)
+	})
+
+	Convey("Log in Combined Log Format", t, func() {
+		var buf bytes.Buffer
+		m := newMacaron(AccessLogOptions{Output: &buf})
+		request(m, "/health")
+		So(buf.String(), shouldMatch, `"GET /health HTTP/1\.1" 200 - "http://example\.com/" "Macaron"\n$`)
+	})
+
+	Convey("Log in JSON", t, func() {
+		var buf bytes.Buffer
+		m := newMacaron(AccessLogOptions{
+			Format: AccessLogJSON,
+			Output: &buf,
+			Fields: []string{AccessLogFieldStatus, AccessLogFieldBytes, AccessLogFieldRoute, AccessLogFieldRequestID, AccessLogFieldLatency},
+		})
+		request(m, "/user/1")
+
+		So(buf.String(), ShouldStartWith, `{"status":200,"bytes":5,"route":"/user/:id","request_id":"abc","latency_ms":`)
+		var record map[string]interface{}
+		So(json.Unmarshal(buf.Bytes(), &record), ShouldBeNil)
+		So(record, ShouldHaveLength, 5)
+	})
+
+	Convey("Log through slog", t, func() {
+		var buf bytes.Buffer
+		m := newMacaron(AccessLogOptions{
+			Format: AccessLogSlog,
+			Logger: slog.New(slog.NewTextHandler(&buf, nil)),
+			Fields: []string{AccessLogFieldMethod, AccessLogFieldURI, AccessLogFieldStatus, AccessLogFieldRemoteAddr},
+		})
+		request(m, "/user/1")
+		request(m, "/panic")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		So(lines, ShouldHaveLength, 2)
+		So(lines[0], ShouldEndWith, `level=INFO msg=access method=GET uri=/user/1 status=200 remote_addr=127.0.0.1`)
+		So(lines[1], ShouldContainSubstring, `level=ERROR msg=access method=GET uri=/panic status=500`)
+	})
+
+	Convey("Sample and skip records", t, func() {
+		var buf bytes.Buffer
+		m := newMacaron(AccessLogOptions{
+			Format:     AccessLogCommon,
+			Output:     &buf,
+			SampleRate: 0.000001,
+			Skip: func(ctx *Context) bool {
+				return ctx.RoutePattern() == "/health"
+			},
+		})
+		for i := 0; i < 10; i++ {
+			request(m, "/user/1")
+			request(m, "/health")
+		}
+		request(m, "/panic")
+		So(regexp.MustCompile(`(?m)^.+$`).FindAllString(buf.String(), -1), ShouldHaveLength, 1)
+		So(buf.String(), ShouldContainSubstring, `"GET /panic HTTP/1.1" 500`)
+	})
+
+	Convey("Use invalid options", t, func() {
+		So(func() { AccessLog(AccessLogOptions{Format: "xml"}) }, ShouldPanic)
+		So(func() { AccessLog(AccessLogOptions{Fields: []string{"foo"}}) }, ShouldPanic)
+	})
+}
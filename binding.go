@@ -0,0 +1,664 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Classifications of binding errors.
+const (
+	ERR_CONTENT_TYPE    = "ContentTypeError"
+	ERR_DESERIALIZATION = "DeserializationError"
+	ERR_TYPE            = "TypeError"
+	ERR_REQUIRED        = "RequiredError"
+	ERR_MIN             = "MinError"
+	ERR_MAX             = "MaxError"
+	ERR_MIN_SIZE        = "MinSizeError"
+	ERR_MAX_SIZE        = "MaxSizeError"
+	ERR_RANGE           = "RangeError"
+	ERR_REGEXP          = "RegexpError"
+	ERR_EMAIL           = "EmailError"
+	ERR_URL             = "UrlError"
+)
+
+// BindingError represents an error occurred while binding request data to a struct.
+type BindingError struct {
+	// Field is the name of the field that the error applies to,
+	// it is empty when the error applies to the whole request.
+	Field string `json:"field,omitempty" xml:"field,omitempty"`
+	// Classification is the kind of the error, e.g. ERR_REQUIRED.
+	Classification string `json:"classification" xml:"classification"`
+	// Message is a human readable description of the error.
+	Message string `json:"message" xml:"message"`
+}
+
+// BindingErrors represents all errors occurred while binding request data to a struct.
+type BindingErrors []BindingError
+
+// Add appends a new error with given field, classification and message.
+func (errs *BindingErrors) Add(field, classification, message string) {
+	*errs = append(*errs, BindingError{field, classification, message})
+}
+
+// Len returns the number of errors.
+func (errs BindingErrors) Len() int {
+	return len(errs)
+}
+
+// Has returns true if there is any error of given classification.
+func (errs BindingErrors) Has(classification string) bool {
+	for i := range errs {
+		if errs[i].Classification == classification {
+			return true
+		}
+	}
+	return false
+}
+
+// Field returns errors that apply to the field of given name.
+func (errs BindingErrors) Field(name string) BindingErrors {
+	var fieldErrs BindingErrors
+	for i := range errs {
+		if errs[i].Field == name {
+			fieldErrs = append(fieldErrs, errs[i])
+		}
+	}
+	return fieldErrs
+}
+
+// Error implements the error interface.
+func (errs BindingErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i := range errs {
+		if len(errs[i].Field) > 0 {
+			msgs[i] = errs[i].Field + ": " + errs[i].Message
+		} else {
+			msgs[i] = errs[i].Message
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validator is the interface that bound structs implement to perform custom
+// validation after the rules declared in binding tags have been checked.
+type Validator interface {
+	Validate(*Context, BindingErrors) BindingErrors
+}
+
+// BindingRule reports whether the value of a field satisfies the rule,
+// arg is the content in parentheses of the rule in binding tag, e.g. "Rule(arg)".
+type BindingRule func(value interface{}, arg string) bool
+
+var bindingRules = struct {
+	lock  sync.RWMutex
+	rules map[string]BindingRule
+}{rules: make(map[string]BindingRule)}
+
+// AddBindingRule registers a custom rule with given name which can be used in binding tags.
+// The classification of errors reported by the rule is the name with "Error" suffix.
+// Rules must be added before binding the types using them.
+func AddBindingRule(name string, rule BindingRule) {
+	bindingRules.lock.Lock()
+	defer bindingRules.lock.Unlock()
+
+	bindingRules.rules[name] = rule
+}
+
+func getBindingRule(name string) BindingRule {
+	bindingRules.lock.RLock()
+	defer bindingRules.lock.RUnlock()
+
+	return bindingRules.rules[name]
+}
+
+// Bind returns a middleware handler that binds request data into a new instance of
+// the type of obj, and maps the instance and the BindingErrors to the context.
+// The obj can be a struct or a pointer to struct, which decides what is mapped.
+//
+// Data is decoded by Content-Type of the request: JSON for "application/json",
+// XML for "text/xml" and "application/xml", and form values including query
+// for others. Struct fields are bound by the "form" tag and validated by the
+// rules separated by semicolon in the "binding" tag, e.g.:
+//
+//	type SignUp struct {
+//		Name  string `form:"name" json:"name" binding:"Required;MaxSize(20)"`
+//		Email string `form:"email" json:"email" binding:"Required;Email"`
+//		Age   int    `form:"age" json:"age" binding:"Range(18,150)"`
+//	}
+//
+// Built-in rules are Required, Min(n), Max(n), MinSize(n), MaxSize(n), Range(min,max),
+// Regexp(pattern), Email and Url, patterns of Regexp must not contain semicolons.
+// Rules other than Required are skipped for zero values. Rules are parsed once per
+// type, and Bind panics when any of them is unknown or has an invalid argument.
+func Bind(obj interface{}) Handler {
+	typ := reflect.TypeOf(obj)
+	isPtr := typ != nil && typ.Kind() == reflect.Ptr
+	if isPtr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		panic("binding object must be a struct or a pointer to struct")
+	}
+	structRules(typ)
+	provided := []reflect.Type{reflect.TypeOf(obj), reflect.TypeOf(BindingErrors(nil))}
+
+	return &providerHandler{provided: provided, handle: func(ctx *Context) {
+		v := reflect.New(typ)
+		errs := ctx.Bind(v.Interface())
+		if isPtr {
+			ctx.Map(v.Interface())
+		} else {
+			ctx.Map(v.Elem().Interface())
+		}
+		ctx.Map(errs)
+	}}
+}
+
+// Bind binds request data into obj which must be a pointer to struct, and validates it.
+// See macaron.Bind for how data is decoded and validated, rules of the type are parsed
+// at the first time it is bound.
+func (ctx *Context) Bind(obj interface{}) BindingErrors {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic("binding object must be a pointer to struct")
+	}
+
+	var errs BindingErrors
+	contentType := ctx.Req.Header.Get(_CONTENT_TYPE)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == _CONTENT_JSON || strings.HasSuffix(mediaType, "+json"):
+		bindBody(ctx.Req.Request.Body, obj, json.NewDecoder(ctx.Req.Request.Body).Decode, &errs)
+	case mediaType == _CONTENT_XML || mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml"):
+		bindBody(ctx.Req.Request.Body, obj, xml.NewDecoder(ctx.Req.Request.Body).Decode, &errs)
+	case mediaType == "multipart/form-data":
+		if err := ctx.Req.ParseMultipartForm(MaxMemory); err != nil {
+			errs.Add("", ERR_DESERIALIZATION, err.Error())
+			break
+		}
+		bindForm(ctx.Req.Form, ctx.Req.MultipartForm.File, v.Elem(), &errs)
+	case len(mediaType) == 0 || mediaType == "application/x-www-form-urlencoded" || ctx.Req.ContentLength == 0:
+		if err := ctx.Req.ParseForm(); err != nil {
+			errs.Add("", ERR_DESERIALIZATION, err.Error())
+			break
+		}
+		bindForm(ctx.Req.Form, nil, v.Elem(), &errs)
+	default:
+		errs.Add("", ERR_CONTENT_TYPE, "unsupported content type: "+contentType)
+	}
+
+	validateStruct(v.Elem(), "", &errs)
+	if validator, ok := obj.(Validator); ok {
+		errs = validator.Validate(ctx, errs)
+	}
+	return errs
+}
+
+func bindBody(body io.Reader, obj interface{}, decode func(interface{}) error, errs *BindingErrors) {
+	if body == nil {
+		return
+	}
+	// An empty body is left to be reported by validation rules.
+	if err := decode(obj); err != nil && !errors.Is(err, io.EOF) {
+		errs.Add("", ERR_DESERIALIZATION, err.Error())
+	}
+}
+
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// bindForm sets struct fields by given form values and files.
+func bindForm(form url.Values, files map[string][]*multipart.FileHeader, v reflect.Value, errs *BindingErrors) {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fv := v.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("form")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous || (!ok && field.Type.Kind() == reflect.Struct) {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					if !fv.CanSet() {
+						continue
+					}
+					fv.Set(reflect.New(field.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				bindForm(form, files, fv, errs)
+			}
+			continue
+		}
+		if !ok {
+			name = field.Name
+		}
+
+		switch {
+		case field.Type == fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+			continue
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem() == fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+			continue
+		}
+
+		vals, ok := form[name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Slice {
+			slice := reflect.MakeSlice(field.Type, len(vals), len(vals))
+			for j := range vals {
+				if err := setFormValue(slice.Index(j), vals[j]); err != nil {
+					errs.Add(name, ERR_TYPE, err.Error())
+				}
+			}
+			fv.Set(slice)
+			continue
+		}
+
+		if err := setFormValue(fv, vals[0]); err != nil {
+			errs.Add(name, ERR_TYPE, err.Error())
+		}
+	}
+}
+
+// setFormValue parses val into the type of v and sets it, empty val leaves v untouched.
+func setFormValue(v reflect.Value, val string) error {
+	if len(val) == 0 {
+		return nil
+	}
+
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setFormValue(ptr.Elem(), val); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("value %q is not a bool", val)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("value %q is not an integer", val)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(val, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("value %q is not an unsigned integer", val)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("value %q is not a number", val)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+// fieldName returns the name of a struct field used in binding errors,
+// which is the first name given by form, json and xml tags, or the field name.
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"form", "json", "xml"} {
+		name := strings.Split(field.Tag.Get(key), ",")[0]
+		if len(name) > 0 && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// validateStruct checks rules in binding tags of all fields of given struct recursively.
+func validateStruct(v reflect.Value, prefix string, errs *BindingErrors) {
+	typ := v.Type()
+	rules := structRules(typ)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		fv := v.Field(i)
+
+		name := prefix + fieldName(field)
+		if field.Anonymous {
+			name = strings.TrimSuffix(prefix, ".")
+		}
+		validateField(fv, name, rules[i], errs)
+
+		if fv.Kind() == reflect.Ptr && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		nestedPrefix := name + "."
+		if len(name) == 0 {
+			nestedPrefix = ""
+		}
+		switch {
+		case fv.Kind() == reflect.Struct:
+			validateStruct(fv, nestedPrefix, errs)
+		case fv.Kind() == reflect.Slice && indirectType(fv.Type().Elem()).Kind() == reflect.Struct:
+			for j := 0; j < fv.Len(); j++ {
+				elem := fv.Index(j)
+				if elem.Kind() == reflect.Ptr {
+					if elem.IsNil() {
+						continue
+					}
+					elem = elem.Elem()
+				}
+				validateStruct(elem, name+"["+strconv.Itoa(j)+"].", errs)
+			}
+		}
+	}
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Ptr {
+		return typ.Elem()
+	}
+	return typ
+}
+
+// parseRule splits a rule into its name and argument, e.g. "Range(1,10)" into "Range" and "1,10".
+func parseRule(rule string) (name, arg string) {
+	i := strings.Index(rule, "(")
+	if i == -1 || !strings.HasSuffix(rule, ")") {
+		return rule, ""
+	}
+	return rule[:i], rule[i+1 : len(rule)-1]
+}
+
+// isZero returns true if given value is the zero value or an empty collection.
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// validateField checks the value of a field against given rules.
+func validateField(v reflect.Value, name string, rules []fieldRule, errs *BindingErrors) {
+	if len(rules) == 0 {
+		return
+	}
+
+	zero := isZero(v)
+	for _, rule := range rules {
+		if rule.name == "Required" {
+			if zero {
+				errs.Add(name, ERR_REQUIRED, "is required")
+				return
+			}
+			continue
+		} else if zero {
+			continue
+		}
+
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		if classification, msg := rule.check(v); len(classification) > 0 {
+			errs.Add(name, classification, msg)
+		}
+	}
+}
+
+// numberOf returns the value as float64 if it is a number.
+func numberOf(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// sizeOf returns the number of characters of a string or the length of a collection.
+func sizeOf(v reflect.Value) (int, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len(), true
+	}
+	return 0, false
+}
+
+// fieldRule is a rule in binding tag with its argument parsed.
+type fieldRule struct {
+	name string
+	// check checks a non-zero value against the rule, it returns the classification
+	// and message of the error if the value does not satisfy the rule.
+	check func(v reflect.Value) (classification, msg string)
+}
+
+var structRulesCache sync.Map // map[reflect.Type][][]fieldRule
+
+// structRules returns rules in binding tags of given struct type by field index, rules are
+// parsed once per type. It panics when any rule of the struct or its nested structs is
+// unknown or has an invalid argument.
+func structRules(typ reflect.Type) [][]fieldRule {
+	if rules, ok := structRulesCache.Load(typ); ok {
+		return rules.([][]fieldRule)
+	}
+
+	parsed := make(map[reflect.Type][][]fieldRule)
+	parseStructRules(typ, parsed)
+	for t, rules := range parsed {
+		structRulesCache.Store(t, rules)
+	}
+	return parsed[typ]
+}
+
+// parseStructRules parses rules of given struct type and struct types of its fields into parsed.
+func parseStructRules(typ reflect.Type, parsed map[reflect.Type][][]fieldRule) {
+	if _, ok := parsed[typ]; ok {
+		return
+	} else if _, ok = structRulesCache.Load(typ); ok {
+		return
+	}
+
+	rules := make([][]fieldRule, typ.NumField())
+	parsed[typ] = rules
+	for i := range rules {
+		field := typ.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		if tag := field.Tag.Get("binding"); tag != "-" {
+			rules[i] = parseRules(field.Name, tag)
+		}
+
+		elemType := indirectType(field.Type)
+		if elemType.Kind() == reflect.Slice {
+			elemType = indirectType(elemType.Elem())
+		}
+		if elemType.Kind() == reflect.Struct {
+			parseStructRules(elemType, parsed)
+		}
+	}
+}
+
+// parseRules parses rules separated by semicolon in the binding tag of given field.
+func parseRules(field, tag string) []fieldRule {
+	var rules []fieldRule
+	for _, rule := range strings.Split(tag, ";") {
+		rule = strings.TrimSpace(rule)
+		if len(rule) == 0 {
+			continue
+		}
+		name, arg := parseRule(rule)
+		rules = append(rules, newFieldRule(field, name, arg))
+	}
+	return rules
+}
+
+// newFieldRule returns the rule of given name with the argument parsed, it panics when
+// the rule is unknown or the argument is invalid.
+func newFieldRule(field, name, arg string) fieldRule {
+	invalid := func(reason string) {
+		panic("invalid argument of binding rule " + name + " of field " + field + ": " + reason)
+	}
+
+	rule := fieldRule{name: name}
+	switch name {
+	case "Required":
+	case "Min", "Max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			invalid(arg)
+		}
+		rule.check = func(v reflect.Value) (string, string) {
+			n, ok := numberOf(v)
+			if !ok {
+				return "", ""
+			}
+			if name == "Min" && n < limit {
+				return ERR_MIN, "must be greater than or equal to " + arg
+			} else if name == "Max" && n > limit {
+				return ERR_MAX, "must be less than or equal to " + arg
+			}
+			return "", ""
+		}
+	case "MinSize", "MaxSize":
+		limit, err := strconv.Atoi(arg)
+		if err != nil {
+			invalid(arg)
+		}
+		rule.check = func(v reflect.Value) (string, string) {
+			size, ok := sizeOf(v)
+			if !ok {
+				return "", ""
+			}
+			unit := "elements"
+			if v.Kind() == reflect.String {
+				unit = "characters"
+			}
+			if name == "MinSize" && size < limit {
+				return ERR_MIN_SIZE, "must contain at least " + arg + " " + unit
+			} else if name == "MaxSize" && size > limit {
+				return ERR_MAX_SIZE, "must contain at most " + arg + " " + unit
+			}
+			return "", ""
+		}
+	case "Range":
+		bounds := strings.Split(arg, ",")
+		if len(bounds) != 2 {
+			invalid(arg)
+		}
+		lower, upper := strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[1])
+		min, err1 := strconv.ParseFloat(lower, 64)
+		max, err2 := strconv.ParseFloat(upper, 64)
+		if err1 != nil || err2 != nil {
+			invalid(arg)
+		}
+		rule.check = func(v reflect.Value) (string, string) {
+			n, ok := numberOf(v)
+			if ok && (n < min || n > max) {
+				return ERR_RANGE, "must be between " + lower + " and " + upper
+			}
+			return "", ""
+		}
+	case "Regexp":
+		reg, err := regexp.Compile(arg)
+		if err != nil {
+			invalid(err.Error())
+		}
+		rule.check = func(v reflect.Value) (string, string) {
+			if v.Kind() == reflect.String && !reg.MatchString(v.String()) {
+				return ERR_REGEXP, "must match " + arg
+			}
+			return "", ""
+		}
+	case "Email":
+		rule.check = func(v reflect.Value) (string, string) {
+			if v.Kind() != reflect.String {
+				return "", ""
+			}
+			addr, err := mail.ParseAddress(v.String())
+			if err != nil || addr.Address != v.String() {
+				return ERR_EMAIL, "is not a valid email address"
+			}
+			return "", ""
+		}
+	case "Url":
+		rule.check = func(v reflect.Value) (string, string) {
+			if v.Kind() != reflect.String {
+				return "", ""
+			}
+			u, err := url.ParseRequestURI(v.String())
+			if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+				return ERR_URL, "is not a valid URL"
+			}
+			return "", ""
+		}
+	default:
+		custom := getBindingRule(name)
+		if custom == nil {
+			panic("unknown binding rule " + name + " of field " + field)
+		}
+		rule.check = func(v reflect.Value) (string, string) {
+			if !custom(v.Interface(), arg) {
+				return name + "Error", "does not satisfy rule " + name
+			}
+			return "", ""
+		}
+	}
+	return rule
+}
@@ -0,0 +1,257 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type bindingAddress struct {
+	City string `form:"city" json:"city" xml:"city" binding:"Required"`
+}
+
+type bindingPost struct {
+	Title    string          `form:"title" json:"title" xml:"title" binding:"Required;MaxSize(10)"`
+	Content  string          `form:"content" json:"content" xml:"content"`
+	Views    int             `form:"views" json:"views" xml:"views" binding:"Range(1,100)"`
+	Score    float64         `form:"score" json:"score" xml:"score" binding:"Min(0);Max(5)"`
+	Tags     []string        `form:"tag" json:"tags" xml:"tags" binding:"MinSize(1)"`
+	Email    string          `form:"email" json:"email" xml:"email" binding:"Email"`
+	Website  string          `form:"website" json:"website" xml:"website" binding:"Url"`
+	Slug     string          `form:"slug" json:"slug" xml:"slug" binding:"Regexp(^[a-z-]+$)"`
+	Draft    *bool           `form:"draft" json:"draft" xml:"draft"`
+	Address  *bindingAddress `json:"address" xml:"address"`
+	Internal string          `form:"-" json:"-" xml:"-"`
+}
+
+type bindingUpload struct {
+	Name  string                  `form:"name"`
+	File  *multipart.FileHeader   `form:"file" binding:"Required"`
+	Files []*multipart.FileHeader `form:"files"`
+}
+
+type bindingValidated struct {
+	Password string `form:"password"`
+	Confirm  string `form:"confirm"`
+}
+
+func (v bindingValidated) Validate(_ *Context, errs BindingErrors) BindingErrors {
+	if v.Password != v.Confirm {
+		errs.Add("confirm", "MismatchError", "does not match password")
+	}
+	return errs
+}
+
+func performBinding(m *Macaron, method, target, contentType string, body *bytes.Buffer) *httptest.ResponseRecorder {
+	if body == nil {
+		body = &bytes.Buffer{}
+	}
+	req, err := http.NewRequest(method, target, body)
+	So(err, ShouldBeNil)
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	return resp
+}
+
+func Test_Bind(t *testing.T) {
+	Convey("Bind form data", t, func() {
+		m := New()
+		m.Post("/", Bind(bindingPost{}), func(post bindingPost, errs BindingErrors) {
+			So(errs, ShouldBeEmpty)
+			So(post.Title, ShouldEqual, "Hello")
+			So(post.Views, ShouldEqual, 10)
+			So(post.Score, ShouldEqual, 4.5)
+			So(post.Tags, ShouldResemble, []string{"go", "web"})
+			So(*post.Draft, ShouldBeTrue)
+			So(post.Internal, ShouldBeEmpty)
+		})
+		performBinding(m, "POST", "/?views=10", "application/x-www-form-urlencoded",
+			bytes.NewBufferString("title=Hello&score=4.5&tag=go&tag=web&draft=true&Internal=x"))
+	})
+
+	Convey("Bind query of GET request into pointer", t, func() {
+		m := New()
+		m.Get("/", Bind(&bindingPost{}), func(post *bindingPost, errs BindingErrors) {
+			So(errs, ShouldBeEmpty)
+			So(post.Title, ShouldEqual, "Hello")
+			So(post.Slug, ShouldEqual, "hello-world")
+		})
+		performBinding(m, "GET", "/?title=Hello&slug=hello-world", "", nil)
+	})
+
+	Convey("Bind JSON data", t, func() {
+		m := New()
+		m.Post("/", Bind(bindingPost{}), func(post bindingPost, errs BindingErrors) {
+			So(errs, ShouldBeEmpty)
+			So(post.Title, ShouldEqual, "Hello")
+			So(post.Address.City, ShouldEqual, "Beijing")
+		})
+		performBinding(m, "POST", "/", "application/json; charset=utf-8",
+			bytes.NewBufferString(`{"title":"Hello","address":{"city":"Beijing"}}`))
+	})
+
+	Convey("Bind XML data", t, func() {
+		m := New()
+		m.Post("/", Bind(bindingPost{}), func(post bindingPost, errs BindingErrors) {
+			So(errs, ShouldBeEmpty)
+			So(post.Title, ShouldEqual, "Hello")
+			So(post.Views, ShouldEqual, 3)
+		})
+		performBinding(m, "POST", "/", "application/xml",
+			bytes.NewBufferString(`<bindingPost><title>Hello</title><views>3</views></bindingPost>`))
+	})
+
+	Convey("Bind multipart form data", t, func() {
+		body := &bytes.Buffer{}
+		w := multipart.NewWriter(body)
+		So(w.WriteField("name", "avatar"), ShouldBeNil)
+		fw, err := w.CreateFormFile("file", "avatar.png")
+		So(err, ShouldBeNil)
+		_, _ = fw.Write([]byte("PNG"))
+		for _, name := range []string{"a.txt", "b.txt"} {
+			fw, err = w.CreateFormFile("files", name)
+			So(err, ShouldBeNil)
+			_, _ = fw.Write([]byte(name))
+		}
+		So(w.Close(), ShouldBeNil)
+
+		m := New()
+		m.Post("/", Bind(bindingUpload{}), func(upload bindingUpload, errs BindingErrors) {
+			So(errs, ShouldBeEmpty)
+			So(upload.Name, ShouldEqual, "avatar")
+			So(upload.File.Filename, ShouldEqual, "avatar.png")
+			So(upload.Files, ShouldHaveLength, 2)
+		})
+		performBinding(m, "POST", "/", w.FormDataContentType(), body)
+	})
+
+	Convey("Report validation errors", t, func() {
+		m := New()
+		m.Post("/", Bind(bindingPost{}), func(errs BindingErrors) {
+			So(errs.Field("title"), ShouldHaveLength, 1)
+			So(errs.Field("title")[0].Classification, ShouldEqual, ERR_MAX_SIZE)
+			So(errs.Field("views")[0].Classification, ShouldEqual, ERR_RANGE)
+			So(errs.Field("score")[0].Classification, ShouldEqual, ERR_MAX)
+			So(errs.Field("email")[0].Classification, ShouldEqual, ERR_EMAIL)
+			So(errs.Field("website")[0].Classification, ShouldEqual, ERR_URL)
+			So(errs.Field("slug")[0].Classification, ShouldEqual, ERR_REGEXP)
+			So(errs.Field("address.city")[0].Classification, ShouldEqual, ERR_REQUIRED)
+			So(errs.Len(), ShouldEqual, 7)
+			So(errs.Error(), ShouldContainSubstring, "title: must contain at most 10 characters")
+		})
+		performBinding(m, "POST", "/", "application/json", bytes.NewBufferString(`{
+			"title": "Hello, world!",
+			"views": 200,
+			"score": 6,
+			"email": "unknwon",
+			"website": "example.com",
+			"slug": "Hello World",
+			"address": {}
+		}`))
+	})
+
+	Convey("Report required and type errors", t, func() {
+		m := New()
+		m.Post("/", Bind(bindingPost{}), func(errs BindingErrors) {
+			So(errs.Has(ERR_REQUIRED), ShouldBeTrue)
+			So(errs.Field("views")[0].Classification, ShouldEqual, ERR_TYPE)
+			So(errs.Field("tag"), ShouldBeEmpty)
+		})
+		performBinding(m, "POST", "/", "application/x-www-form-urlencoded", bytes.NewBufferString("views=many"))
+	})
+
+	Convey("Report deserialization and content type errors", t, func() {
+		m := New()
+		m.Post("/json", Bind(bindingPost{}), func(errs BindingErrors) {
+			So(errs.Has(ERR_DESERIALIZATION), ShouldBeTrue)
+		})
+		performBinding(m, "POST", "/json", "application/json", bytes.NewBufferString(`{"title":`))
+
+		m.Post("/yaml", Bind(bindingPost{}), func(errs BindingErrors) {
+			So(errs.Has(ERR_CONTENT_TYPE), ShouldBeTrue)
+		})
+		performBinding(m, "POST", "/yaml", "application/yaml", bytes.NewBufferString("title: Hello"))
+	})
+
+	Convey("Validate by custom validator", t, func() {
+		m := New()
+		m.Post("/", Bind(bindingValidated{}), func(errs BindingErrors) {
+			So(errs.Has("MismatchError"), ShouldBeTrue)
+		})
+		performBinding(m, "POST", "/", "application/x-www-form-urlencoded", bytes.NewBufferString("password=a&confirm=b"))
+	})
+
+	Convey("Validate by custom rule", t, func() {
+		AddBindingRule("Prefix", func(value interface{}, arg string) bool {
+			s, ok := value.(string)
+			return ok && strings.HasPrefix(s, arg)
+		})
+		type form struct {
+			Code string `form:"code" binding:"Prefix(MAC-)"`
+		}
+
+		m := New()
+		m.Get("/", func(ctx *Context) {
+			var f form
+			errs := ctx.Bind(&f)
+			So(errs.Has("PrefixError"), ShouldEqual, ctx.Query("invalid") == "1")
+		})
+		performBinding(m, "GET", "/?code=MAC-1", "", nil)
+		performBinding(m, "GET", "/?code=XYZ-1&invalid=1", "", nil)
+	})
+
+	Convey("Bind invalid objects", t, func() {
+		So(func() { Bind("string") }, ShouldPanic)
+		So(func() { Bind(nil) }, ShouldPanic)
+
+		type unknownRule struct {
+			Name string `binding:"Required;Unknown"`
+		}
+		type invalidMin struct {
+			Count int `binding:"Min(x)"`
+		}
+		type invalidRange struct {
+			Count int `binding:"Range(1)"`
+		}
+		type invalidRegexp struct {
+			Name string `binding:"Regexp(a(b)"`
+		}
+		type invalidNested struct {
+			Items []*invalidMin
+		}
+		for _, obj := range []interface{}{unknownRule{}, invalidMin{}, &invalidRange{}, invalidRegexp{}, invalidNested{}} {
+			So(func() { Bind(obj) }, ShouldPanic)
+		}
+
+		type node struct {
+			Name     string `binding:"Required"`
+			Children []*node
+		}
+		So(func() { Bind(node{}) }, ShouldNotPanic)
+
+		ctx := &Context{}
+		So(func() { ctx.Bind(bindingPost{}) }, ShouldPanic)
+	})
+}
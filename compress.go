@@ -0,0 +1,431 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	_CONTENT_ENCODING = "Content-Encoding"
+	_ACCEPT_ENCODING  = "Accept-Encoding"
+)
+
+// Compressor creates a writer that compresses data written to it into w at given level.
+// Close is called on the writer once the response is done, and Flush() error is called
+// on it if implemented when the response is flushed.
+type Compressor func(w io.Writer, level int) (io.WriteCloser, error)
+
+// CompressOptions represents a struct for specifying configuration options for the Compress middleware.
+type CompressOptions struct {
+	// Level of compression passed to compressors. Default is the default level of each compressor.
+	Level int
+	// MinSize is the minimal size in bytes of response body to compress. Default is 1024.
+	MinSize int
+	// Compressors maps content codings to compressors, in addition to the built-in "gzip" and "deflate".
+	// The standard library implements neither brotli nor zstd, register compressors of third-party
+	// packages as "br" and "zstd" to have them negotiated.
+	Compressors map[string]Compressor
+	// Encodings is the content codings in the order of server preference.
+	// Default is "zstd", "br", "gzip" and then "deflate". Unregistered ones are ignored.
+	Encodings []string
+	// ExcludedContentTypes is the media types not to compress, a media type ending with "/" matches
+	// all of its subtypes. Default is DefaultExcludedContentTypes.
+	ExcludedContentTypes []string
+}
+
+// DefaultExcludedContentTypes is the media types that are already compressed.
+var DefaultExcludedContentTypes = []string{
+	"image/",
+	"audio/",
+	"video/",
+	"font/woff",
+	"font/woff2",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zip",
+	"application/x-bzip2",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/zstd",
+	"application/pdf",
+	_CONTENT_MSGPACK,
+}
+
+var compressorPools sync.Map // map[string]*sync.Pool, keyed by content coding and level.
+
+// pooledCompressor is a compressor that puts itself back to the pool once closed.
+type pooledCompressor struct {
+	io.WriteCloser
+	reset func(io.Writer)
+	pool  *sync.Pool
+}
+
+func (c *pooledCompressor) Flush() error {
+	if f, ok := c.WriteCloser.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (c *pooledCompressor) Close() error {
+	err := c.WriteCloser.Close()
+	c.reset(io.Discard)
+	c.pool.Put(c)
+	return err
+}
+
+// pooled returns a compressor that reuses writers created by newWriter.
+func pooled(coding string, newWriter func(w io.Writer, level int) (io.WriteCloser, func(io.Writer), error)) Compressor {
+	return func(w io.Writer, level int) (io.WriteCloser, error) {
+		v, _ := compressorPools.LoadOrStore(coding+":"+strconv.Itoa(level), &sync.Pool{})
+		pool := v.(*sync.Pool)
+		if c, ok := pool.Get().(*pooledCompressor); ok {
+			c.reset(w)
+			return c, nil
+		}
+
+		wc, reset, err := newWriter(w, level)
+		if err != nil {
+			return nil, err
+		}
+		return &pooledCompressor{wc, reset, pool}, nil
+	}
+}
+
+// GzipCompressor is the compressor of "gzip" content coding.
+var GzipCompressor = pooled("gzip", func(w io.Writer, level int) (io.WriteCloser, func(io.Writer), error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	gz, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gz, gz.Reset, nil
+})
+
+// DeflateCompressor is the compressor of "deflate" content coding.
+var DeflateCompressor = pooled("deflate", func(w io.Writer, level int) (io.WriteCloser, func(io.Writer), error) {
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+	fw, err := flate.NewWriter(w, level)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fw, fw.Reset, nil
+})
+
+func prepareCompressOptions(options []CompressOptions) CompressOptions {
+	var opt CompressOptions
+	if len(options) > 0 {
+		opt = options[0]
+	}
+
+	// Defaults.
+	if opt.MinSize == 0 {
+		opt.MinSize = 1024
+	}
+	if len(opt.Encodings) == 0 {
+		opt.Encodings = []string{"zstd", "br", "gzip", "deflate"}
+	}
+	if opt.ExcludedContentTypes == nil {
+		opt.ExcludedContentTypes = DefaultExcludedContentTypes
+	}
+
+	// Copy compressors to not modify the map of caller.
+	compressors := map[string]Compressor{
+		"gzip":    GzipCompressor,
+		"deflate": DeflateCompressor,
+	}
+	for coding, c := range opt.Compressors {
+		compressors[strings.ToLower(coding)] = c
+	}
+	opt.Compressors = compressors
+
+	encodings := make([]string, 0, len(opt.Encodings))
+	for _, coding := range opt.Encodings {
+		coding = strings.ToLower(coding)
+		if compressors[coding] != nil {
+			encodings = append(encodings, coding)
+		}
+	}
+	opt.Encodings = encodings
+	return opt
+}
+
+// NegotiateEncoding returns the best content coding of offers acceptable by given Accept-Encoding header,
+// ties are broken by the order of offers. It returns an empty string if none is acceptable, in which
+// case the response should not be encoded.
+func NegotiateEncoding(acceptEncoding string, offers []string) string {
+	qualities := make(map[string]float64, 4)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if len(coding) == 0 {
+			continue
+		}
+
+		q := 1.0
+		key, val, _ := strings.Cut(strings.TrimSpace(params), "=")
+		if strings.ToLower(strings.TrimSpace(key)) == "q" {
+			if v, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil && v >= 0 && v <= 1 {
+				q = v
+			}
+		}
+		qualities[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, ok := qualities[offer]
+		if !ok {
+			q = qualities["*"]
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// matchContentType returns true if the media type of contentType is in given list.
+func matchContentType(contentType string, mediaTypes []string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, typ := range mediaTypes {
+		if mediaType == typ || (strings.HasSuffix(typ, "/") && strings.HasPrefix(mediaType, typ)) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter is a ResponseWriter that compresses the body. It buffers the body until
+// MinSize bytes are written, and decides whether to compress before the header is written.
+type compressWriter struct {
+	ResponseWriter
+	opt        CompressOptions
+	coding     string
+	compressor Compressor
+
+	status    int
+	size      int
+	buf       []byte
+	committed bool
+	closing   bool
+	closed    bool
+	w         io.WriteCloser // The compressor, nil if the body is not compressed.
+}
+
+// decide is called as a BeforeFunc of the underlying ResponseWriter to compress the body if possible.
+func (cw *compressWriter) decide(rw ResponseWriter) {
+	h := rw.Header()
+	switch {
+	case cw.status < http.StatusOK,
+		cw.status == http.StatusNoContent,
+		cw.status == http.StatusPartialContent,
+		cw.status == http.StatusNotModified,
+		len(h.Get(_CONTENT_ENCODING)) > 0:
+		return
+	}
+
+	if len(h.Get(_CONTENT_TYPE)) == 0 && len(cw.buf) > 0 {
+		// It cannot be detected by net/http once compressed.
+		h.Set(_CONTENT_TYPE, http.DetectContentType(cw.buf))
+	}
+	if matchContentType(h.Get(_CONTENT_TYPE), cw.opt.ExcludedContentTypes) {
+		return
+	}
+
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < cw.opt.MinSize {
+		return
+	} else if cw.closing && len(cw.buf) < cw.opt.MinSize {
+		return
+	}
+
+	w, err := cw.compressor(rw, cw.opt.Level)
+	if err != nil {
+		return
+	}
+	cw.w = w
+	h.Set(_CONTENT_ENCODING, cw.coding)
+	h.Del("Content-Length")
+}
+
+// commit writes the header and buffered body to the underlying ResponseWriter.
+func (cw *compressWriter) commit() error {
+	if cw.committed {
+		return nil
+	}
+	cw.committed = true
+
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := cw.writer().Write(buf)
+	return err
+}
+
+func (cw *compressWriter) writer() io.Writer {
+	if cw.w != nil {
+		return cw.w
+	}
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) WriteHeader(s int) {
+	if cw.committed || cw.status != 0 {
+		return
+	}
+	cw.status = s
+	// Responses without body have nothing to wait for.
+	if s < http.StatusOK || s == http.StatusNoContent || s == http.StatusNotModified {
+		_ = cw.commit()
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	cw.size += len(b)
+
+	if !cw.committed {
+		if len(cw.buf)+len(b) < cw.opt.MinSize && !cw.closed {
+			cw.buf = append(cw.buf, b...)
+			return len(b), nil
+		}
+		cw.buf = append(cw.buf, b...)
+		if err := cw.commit(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	return cw.writer().Write(b)
+}
+
+// Status returns the status code of the response or 0 if the response has not been written.
+func (cw *compressWriter) Status() int {
+	return cw.status
+}
+
+// Written returns whether or not the ResponseWriter has been written.
+func (cw *compressWriter) Written() bool {
+	return cw.status != 0
+}
+
+// Size returns the size of the response body before compression.
+func (cw *compressWriter) Size() int {
+	return cw.size
+}
+
+func (cw *compressWriter) Flush() {
+	_ = cw.commit()
+	if f, ok := cw.w.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	cw.ResponseWriter.Flush()
+}
+
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the ResponseWriter doesn't support the Hijacker interface")
+	}
+	return hijacker.Hijack()
+}
+
+// Close writes the buffered body and finishes compression, the body is written
+// uncompressed afterwards.
+func (cw *compressWriter) Close() error {
+	if cw.closed {
+		return nil
+	}
+	cw.closing = true
+	defer func() { cw.closed = true }()
+
+	// Leave the response untouched if nothing has been written.
+	if cw.status == 0 {
+		return nil
+	}
+	if err := cw.commit(); err != nil {
+		return err
+	}
+	if cw.w == nil {
+		return nil
+	}
+	err := cw.w.Close()
+	cw.w = nil
+	return err
+}
+
+// Compress returns a middleware handler that compresses response bodies with the content coding
+// negotiated by the Accept-Encoding header. Bodies smaller than MinSize, of excluded content types
+// or already encoded are not compressed.
+func Compress(options ...CompressOptions) Handler {
+	opt := prepareCompressOptions(options)
+
+	return func(ctx *Context) {
+		addVary(ctx.Resp.Header(), _ACCEPT_ENCODING)
+		coding := NegotiateEncoding(ctx.Req.Header.Get(_ACCEPT_ENCODING), opt.Encodings)
+		if len(coding) == 0 || ctx.Req.Method == "HEAD" {
+			ctx.Next()
+			return
+		}
+
+		rw := ctx.Resp
+		cw := &compressWriter{
+			ResponseWriter: rw,
+			opt:            opt,
+			coding:         coding,
+			compressor:     opt.Compressors[coding],
+		}
+		rw.Before(cw.decide)
+		ctx.Resp = cw
+		ctx.MapTo(cw, (*http.ResponseWriter)(nil))
+		if _, ok := ctx.Render.(*DummyRender); !ok {
+			ctx.Render.SetResponseWriter(cw)
+		}
+		// Let previous handlers see what is actually written.
+		defer func() {
+			_ = cw.Close()
+			ctx.Resp = rw
+			ctx.MapTo(rw, (*http.ResponseWriter)(nil))
+			if _, ok := ctx.Render.(*DummyRender); !ok {
+				ctx.Render.SetResponseWriter(rw)
+			}
+		}()
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_NegotiateEncoding(t *testing.T) {
+	Convey("Negotiate content coding", t, func() {
+		offers := []string{"br", "gzip", "deflate"}
+		So(NegotiateEncoding("", offers), ShouldBeBlank)
+		So(NegotiateEncoding("gzip, deflate, br", offers), ShouldEqual, "br")
+		So(NegotiateEncoding("gzip;q=1.0, br;q=0.5", offers), ShouldEqual, "gzip")
+		So(NegotiateEncoding("deflate, *;q=0.1", offers), ShouldEqual, "deflate")
+		So(NegotiateEncoding("*", offers), ShouldEqual, "br")
+		So(NegotiateEncoding("*, br;q=0", offers), ShouldEqual, "gzip")
+		So(NegotiateEncoding("identity", offers), ShouldBeBlank)
+	})
+}
+
+func Test_Compress(t *testing.T) {
+	large := strings.Repeat("Hello, Macaron! ", 100)
+
+	newMacaron := func(opts ...CompressOptions) *Macaron {
+		m := New()
+		m.Use(Compress(opts...))
+		m.Use(Renderer())
+		m.Get("/large", func() string { return large })
+		m.Get("/small", func() string { return "small" })
+		m.Get("/image", func(ctx *Context) {
+			ctx.Resp.Header().Set(_CONTENT_TYPE, "image/png")
+			ctx.Resp.Write([]byte(large))
+		})
+		m.Get("/encoded", func(ctx *Context) {
+			ctx.Resp.Header().Set(_CONTENT_ENCODING, "gzip")
+			ctx.Resp.Write([]byte(large))
+		})
+		m.Get("/json", func(ctx *Context) {
+			ctx.JSON(http.StatusCreated, map[string]string{"message": large})
+		})
+		m.Get("/flush", func(ctx *Context) {
+			ctx.Resp.Write([]byte("first"))
+			ctx.Resp.Flush()
+			ctx.Resp.Write([]byte("second"))
+		})
+		m.Get("/empty", func(ctx *Context) {
+			ctx.Status(http.StatusNoContent)
+		})
+		return m
+	}
+	request := func(m *Macaron, url, acceptEncoding string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", url, nil)
+		So(err, ShouldBeNil)
+		req.Header.Set(_ACCEPT_ENCODING, acceptEncoding)
+		m.ServeHTTP(resp, req)
+		return resp
+	}
+	gunzip := func(body *bytes.Buffer) string {
+		r, err := gzip.NewReader(body)
+		So(err, ShouldBeNil)
+		p, err := io.ReadAll(r)
+		So(err, ShouldBeNil)
+		return string(p)
+	}
+
+	Convey("Compress response with gzip", t, func() {
+		m := newMacaron()
+		resp := request(m, "/large", "gzip, deflate")
+		So(resp.Code, ShouldEqual, http.StatusOK)
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldEqual, "gzip")
+		So(resp.Header().Get("Vary"), ShouldEqual, _ACCEPT_ENCODING)
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldStartWith, "text/plain")
+		So(resp.Body.Len(), ShouldBeLessThan, len(large))
+		So(gunzip(resp.Body), ShouldEqual, large)
+
+		resp = request(m, "/json", "gzip")
+		So(resp.Code, ShouldEqual, http.StatusCreated)
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldEqual, "gzip")
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldEqual, _CONTENT_JSON+"; charset=UTF-8")
+		So(gunzip(resp.Body), ShouldContainSubstring, large)
+
+		resp = request(m, "/flush", "gzip")
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldEqual, "gzip")
+		So(resp.Flushed, ShouldBeTrue)
+		So(gunzip(resp.Body), ShouldEqual, "firstsecond")
+	})
+
+	Convey("Compress response with deflate", t, func() {
+		resp := request(newMacaron(), "/large", "deflate")
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldEqual, "deflate")
+		p, err := io.ReadAll(flate.NewReader(resp.Body))
+		So(err, ShouldBeNil)
+		So(string(p), ShouldEqual, large)
+	})
+
+	Convey("Compress response with registered compressor", t, func() {
+		m := newMacaron(CompressOptions{
+			MinSize: 1,
+			Compressors: map[string]Compressor{
+				"zstd": func(w io.Writer, level int) (io.WriteCloser, error) {
+					return nopWriteCloser{w}, nil
+				},
+			},
+		})
+		resp := request(m, "/small", "gzip, zstd")
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldEqual, "zstd")
+		So(resp.Body.String(), ShouldEqual, "small")
+	})
+
+	Convey("Skip compression", t, func() {
+		m := newMacaron()
+
+		resp := request(m, "/large", "")
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldBeBlank)
+		So(resp.Header().Get("Vary"), ShouldEqual, _ACCEPT_ENCODING)
+		So(resp.Body.String(), ShouldEqual, large)
+
+		resp = request(m, "/small", "gzip")
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldBeBlank)
+		So(resp.Body.String(), ShouldEqual, "small")
+
+		resp = request(m, "/image", "gzip")
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldBeBlank)
+		So(resp.Body.String(), ShouldEqual, large)
+
+		resp = request(m, "/encoded", "deflate")
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldEqual, "gzip")
+		So(resp.Body.String(), ShouldEqual, large)
+
+		resp = request(m, "/empty", "gzip")
+		So(resp.Code, ShouldEqual, http.StatusNoContent)
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldBeBlank)
+
+		resp = request(m, "/404", "gzip")
+		So(resp.Code, ShouldEqual, http.StatusNotFound)
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldBeBlank)
+	})
+
+	Convey("Preserve status and size of response", t, func() {
+		m := New()
+		m.Use(func(ctx *Context) {
+			ctx.Next()
+			So(ctx.Resp.Status(), ShouldEqual, http.StatusAccepted)
+			So(ctx.Resp.Size(), ShouldBeLessThan, len(large))
+		})
+		m.Use(Compress())
+		m.Get("/", func(ctx *Context, rw http.ResponseWriter) {
+			before := false
+			ctx.Resp.Before(func(ResponseWriter) {
+				before = true
+			})
+			rw.WriteHeader(http.StatusAccepted)
+			So(ctx.Resp.Status(), ShouldEqual, http.StatusAccepted)
+			So(ctx.Resp.Written(), ShouldBeTrue)
+			rw.Write([]byte(large))
+			So(ctx.Resp.Size(), ShouldEqual, len(large))
+			So(before, ShouldBeTrue)
+		})
+		resp := request(m, "/", "gzip")
+		So(resp.Code, ShouldEqual, http.StatusAccepted)
+		So(gunzip(resp.Body), ShouldEqual, large)
+	})
+
+	Convey("Restore response writers for previous handlers", t, func() {
+		m := New()
+		m.Use(Renderer())
+		m.Use(func(ctx *Context) {
+			rw := ctx.Resp
+			ctx.Next()
+			So(ctx.Resp, ShouldEqual, rw)
+			So(ctx.Render.(*TplRender).ResponseWriter, ShouldEqual, rw)
+		})
+		m.Use(Compress())
+		m.Get("/", func() string { return large })
+		resp := request(m, "/", "gzip")
+		So(gunzip(resp.Body), ShouldEqual, large)
+	})
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Constraint restricts routes to requests satisfying it, so that several routes can be
+// registered for the same pattern and method.
+type Constraint interface {
+	// Match returns true if the request satisfies the constraint.
+	Match(req *http.Request) bool
+	// String returns the description of the constraint, constraints with same description
+	// are considered the same.
+	String() string
+}
+
+type headerConstraint struct {
+	name, value string
+}
+
+func (c headerConstraint) Match(req *http.Request) bool {
+	if len(c.value) == 0 {
+		return len(req.Header.Values(c.name)) > 0
+	}
+	return req.Header.Get(c.name) == c.value
+}
+
+func (c headerConstraint) String() string {
+	return "header " + c.name + "=" + c.value
+}
+
+// MatchHeader returns a Constraint satisfied by requests with given header value,
+// or with the header present at all if value is empty.
+func MatchHeader(name, value string) Constraint {
+	return headerConstraint{http.CanonicalHeaderKey(name), value}
+}
+
+type queryConstraint struct {
+	name, value string
+}
+
+func (c queryConstraint) Match(req *http.Request) bool {
+	if len(c.value) == 0 {
+		return req.URL.Query().Has(c.name)
+	}
+	return req.URL.Query().Get(c.name) == c.value
+}
+
+func (c queryConstraint) String() string {
+	return "query " + c.name + "=" + c.value
+}
+
+// MatchQuery returns a Constraint satisfied by requests with given query parameter value,
+// or with the query parameter present at all if value is empty.
+func MatchQuery(name, value string) Constraint {
+	return queryConstraint{name, value}
+}
+
+// When registers routes added by fn to be matched only for requests satisfying given constraint,
+// with given handlers like Group. Routes of same pattern and method are tried in the order they
+// are registered, and the route without constraints is used when no constraint is satisfied.
+// Calls of When can be nested, and routes must satisfy all the constraints.
+func (r *Router) When(c Constraint, fn func(), h ...Handler) {
+	r.constraints = append(r.constraints, c)
+	r.groups = append(r.groups, group{"", h})
+	fn()
+	r.groups = r.groups[:len(r.groups)-1]
+	r.constraints = r.constraints[:len(r.constraints)-1]
+}
+
+// constrainedRoute is a route registered with constraints.
+type constrainedRoute struct {
+	constraints []Constraint
+	handle      Handle
+}
+
+// constrainedRoutes dispatches requests to routes of same pattern and method by their constraints.
+type constrainedRoutes struct {
+	router   *Router
+	routes   []constrainedRoute
+	fallback Handle // The route without constraints.
+}
+
+// add adds a route with given constraints and returns false if the route already exists.
+func (cr *constrainedRoutes) add(constraints []Constraint, handle Handle) bool {
+	if len(constraints) == 0 {
+		if cr.fallback != nil {
+			return false
+		}
+		cr.fallback = handle
+		return true
+	}
+
+	descs := constraintStrings(constraints)
+	for _, route := range cr.routes {
+		if slices.Equal(constraintStrings(route.constraints), descs) {
+			return false
+		}
+	}
+	cr.routes = append(cr.routes, constrainedRoute{slices.Clone(constraints), handle})
+	return true
+}
+
+func (cr *constrainedRoutes) handle(rw http.ResponseWriter, req *http.Request, params Params) {
+	for _, route := range cr.routes {
+		matched := true
+		for _, c := range route.constraints {
+			if !c.Match(req) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			route.handle(rw, req, params)
+			return
+		}
+	}
+
+	if cr.fallback != nil {
+		cr.fallback(rw, req, params)
+	} else {
+		cr.router.notFound(rw, req)
+	}
+}
+
+// constraintStrings returns descriptions of given constraints.
+func constraintStrings(constraints []Constraint) []string {
+	if len(constraints) == 0 {
+		return nil
+	}
+	descs := make([]string, len(constraints))
+	for i := range constraints {
+		descs[i] = constraints[i].String()
+	}
+	return descs
+}
+
+// APIVersionMode is the way clients request a version of API.
+type APIVersionMode int
+
+const (
+	// VersionByAccept takes the version from vendor media types of the Accept header,
+	// e.g. "application/vnd.example.v2+json".
+	VersionByAccept APIVersionMode = iota
+	// VersionByHeader takes the version from a custom header, e.g. "Accept-Version: 2".
+	VersionByHeader
+	// VersionByPath takes the version from the path prefix, e.g. "/v2/users".
+	VersionByPath
+)
+
+// APIVersionOptions represents the way of versioning used by APIVersion.
+type APIVersionOptions struct {
+	// Mode is the way clients request a version. Default is VersionByAccept.
+	Mode APIVersionMode
+	// Vendor is the vendor name in media types of VersionByAccept mode, media types of any
+	// vendor are accepted when it is empty.
+	Vendor string
+	// Header is the name of header in VersionByHeader mode. Default is "Accept-Version".
+	Header string
+	// Default is the version of requests that do not request one in VersionByAccept
+	// and VersionByHeader modes. They are only served by unversioned routes when it is empty.
+	Default string
+}
+
+func prepareAPIVersionOptions(opt APIVersionOptions) APIVersionOptions {
+	// Defaults.
+	if len(opt.Header) == 0 {
+		opt.Header = "Accept-Version"
+	}
+	return opt
+}
+
+// SetAPIVersioning sets the way of versioning used by APIVersion.
+func (r *Router) SetAPIVersioning(opt APIVersionOptions) {
+	r.apiVersioning = prepareAPIVersionOptions(opt)
+}
+
+type versionConstraint struct {
+	opt     APIVersionOptions
+	version string
+}
+
+// acceptVersion returns the version requested by vendor media types of the Accept header,
+// e.g. "2" of "application/vnd.example.v2+json".
+func (c versionConstraint) acceptVersion(req *http.Request) string {
+	for _, accept := range req.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(mediaRange, ";", 2)[0]))
+			if !strings.HasPrefix(mediaType, "application/vnd.") {
+				continue
+			}
+			mediaType = mediaType[len("application/vnd."):]
+			if i := strings.LastIndexByte(mediaType, '+'); i > -1 {
+				mediaType = mediaType[:i]
+			}
+
+			i := strings.LastIndex(mediaType, ".v")
+			if i == -1 || (len(c.opt.Vendor) > 0 && !strings.EqualFold(mediaType[:i], c.opt.Vendor)) {
+				continue
+			}
+			return mediaType[i+2:]
+		}
+	}
+	return ""
+}
+
+func (c versionConstraint) Match(req *http.Request) bool {
+	var version string
+	if c.opt.Mode == VersionByHeader {
+		version = req.Header.Get(c.opt.Header)
+	} else {
+		version = c.acceptVersion(req)
+	}
+	if len(version) == 0 {
+		version = c.opt.Default
+	}
+	return version == c.version
+}
+
+func (c versionConstraint) String() string {
+	return "version " + c.version
+}
+
+// APIVersion registers routes added by fn as given version of API, with given handlers like Group,
+// in the way set by SetAPIVersioning. Routes are prefixed with "/v" and the version in VersionByPath
+// mode, and constrained by the version requested in other modes, so that several versions of API can
+// run side by side with shared middleware, e.g.
+//
+//	m.APIVersion("1", func() { m.Get("/users", listUsers) })
+//	m.APIVersion("2", func() { m.Get("/users", listUsersV2) })
+func (r *Router) APIVersion(version string, fn func(), h ...Handler) {
+	opt := prepareAPIVersionOptions(r.apiVersioning)
+	if opt.Mode == VersionByPath {
+		r.Group("/v"+version, fn, h...)
+		return
+	}
+	r.When(versionConstraint{opt, version}, fn, h...)
+}
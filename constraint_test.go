@@ -0,0 +1,164 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Router_When(t *testing.T) {
+	Convey("Route requests by constraints", t, func() {
+		m := New()
+		m.When(MatchHeader("x-client", "mobile"), func() {
+			m.Get("/feed", func() string { return "mobile feed" })
+			m.When(MatchQuery("compact", ""), func() {
+				m.Get("/feed/:id", func(ctx *Context) string { return "compact mobile " + ctx.Params("id") })
+			})
+		})
+		m.Get("/feed", func() string { return "feed" })
+		m.Get("/feed", func() string { return "duplicate" })
+		m.When(MatchQuery("format", "rss"), func() {
+			m.Get("/feed", func() string { return "rss" })
+			m.Get("/feed", func() string { return "duplicate" })
+		})
+
+		cases := []struct {
+			url, client string
+			code        int
+			body        string
+		}{
+			{"/feed", "", http.StatusOK, "feed"},
+			{"/feed", "mobile", http.StatusOK, "mobile feed"},
+			{"/feed?format=rss", "", http.StatusOK, "rss"},
+			{"/feed?format=rss", "mobile", http.StatusOK, "mobile feed"},
+			{"/feed/1?compact", "mobile", http.StatusOK, "compact mobile 1"},
+			{"/feed/1", "mobile", http.StatusNotFound, "404 page not found\n"},
+			{"/feed/1?compact", "", http.StatusNotFound, "404 page not found\n"},
+		}
+		for _, c := range cases {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", c.url, nil)
+			So(err, ShouldBeNil)
+			if len(c.client) > 0 {
+				req.Header.Set("X-Client", c.client)
+			}
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, c.code)
+			So(resp.Body.String(), ShouldEqual, c.body)
+		}
+
+		routes := m.Routes()
+		So(routes, ShouldHaveLength, 4)
+		So(routes[0].Constraints, ShouldResemble, []string{"header X-Client=mobile"})
+		So(routes[1].Constraints, ShouldBeEmpty)
+		So(routes[2].Constraints, ShouldResemble, []string{"query format=rss"})
+		So(routes[3].Constraints, ShouldResemble, []string{"header X-Client=mobile", "query compact="})
+		So(routes[3].route(), ShouldEqual, "/feed/:id (header X-Client=mobile, query compact=)")
+	})
+}
+
+func Test_Router_APIVersion(t *testing.T) {
+	register := func(m *Macaron) {
+		m.Use(func(ctx *Context) { ctx.Resp.Header().Set("X-Middleware", "true") })
+		m.APIVersion("1", func() {
+			m.Get("/users", func() string { return "v1" })
+		})
+		m.APIVersion("2", func() {
+			m.Get("/users", func() string { return "v2" })
+		}, func(ctx *Context) { ctx.Resp.Header().Set("X-Version", "2") })
+	}
+	serve := func(m *Macaron, url string, header http.Header) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", url, nil)
+		So(err, ShouldBeNil)
+		req.Header = header
+		m.ServeHTTP(resp, req)
+		So(resp.Header().Get("X-Middleware"), ShouldEqual, "true")
+		return resp
+	}
+
+	Convey("Version by Accept header", t, func() {
+		m := New()
+		m.SetAPIVersioning(APIVersionOptions{Vendor: "example", Default: "1"})
+		register(m)
+
+		cases := []struct {
+			accept, body string
+		}{
+			{"", "v1"},
+			{"application/json", "v1"},
+			{"application/vnd.example.v1+json", "v1"},
+			{"application/vnd.example.v2+json", "v2"},
+			{"text/html, application/vnd.example.v2+json; q=0.9", "v2"},
+			{"application/vnd.other.v2+json", "v1"},
+		}
+		for _, c := range cases {
+			resp := serve(m, "/users", http.Header{"Accept": {c.accept}})
+			So(resp.Body.String(), ShouldEqual, c.body)
+		}
+		So(serve(m, "/users", http.Header{"Accept": {"application/vnd.example.v2+json"}}).Header().Get("X-Version"), ShouldEqual, "2")
+		So(serve(m, "/users", http.Header{"Accept": {"application/vnd.example.v3+json"}}).Code, ShouldEqual, http.StatusNotFound)
+		So(m.Routes()[1].Constraints, ShouldResemble, []string{"version 2"})
+	})
+
+	Convey("Encode returned values of versioned routes", t, func() {
+		type user struct {
+			Name string `json:"name"`
+		}
+		m := New()
+		m.Use(Renderer())
+		m.SetAPIVersioning(APIVersionOptions{Vendor: "example"})
+		m.APIVersion("2", func() {
+			m.Get("/users", func() []user { return []user{{"unknwon"}} })
+			m.Get("/user", Typed(func(ctx *Context, in struct{}) (user, error) { return user{"created"}, nil }))
+		})
+
+		for url, body := range map[string]string{"/users": `[{"name":"unknwon"}]`, "/user": `{"name":"created"}`} {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", url, nil)
+			So(err, ShouldBeNil)
+			req.Header.Set("Accept", "application/vnd.example.v2+json")
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+			So(resp.Header().Get("Content-Type"), ShouldStartWith, "application/json")
+			So(resp.Body.String(), ShouldEqual, body)
+		}
+	})
+
+	Convey("Version by custom header", t, func() {
+		m := New()
+		m.SetAPIVersioning(APIVersionOptions{Mode: VersionByHeader})
+		register(m)
+
+		So(serve(m, "/users", http.Header{"Accept-Version": {"1"}}).Body.String(), ShouldEqual, "v1")
+		So(serve(m, "/users", http.Header{"Accept-Version": {"2"}}).Body.String(), ShouldEqual, "v2")
+		So(serve(m, "/users", http.Header{}).Code, ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("Version by path prefix", t, func() {
+		m := New()
+		m.SetAPIVersioning(APIVersionOptions{Mode: VersionByPath})
+		register(m)
+
+		So(serve(m, "/v1/users", http.Header{}).Body.String(), ShouldEqual, "v1")
+		So(serve(m, "/v2/users", http.Header{}).Body.String(), ShouldEqual, "v2")
+		So(serve(m, "/users", http.Header{}).Code, ShouldEqual, http.StatusNotFound)
+		So(m.Routes()[1].Groups, ShouldResemble, []string{"/v2"})
+	})
+}
@@ -83,19 +83,30 @@ func (invoke ContextInvoker) Invoke(params []interface{}) ([]reflect.Value, erro
 
 // Context represents the runtime context of current request of Macaron instance.
 // It is the integration of most frequently used middlewares and helper methods.
+//
+// Contexts are pooled and reused by requests, so a Context and its Data must not be used
+// once the request is served, e.g. by goroutines started by handlers.
 type Context struct {
 	inject.Injector
+	inj      *injector
 	handlers []Handler
 	action   Handler
 	index    int
 
 	*Router
-	Req    Request
-	Resp   ResponseWriter
-	params Params
+	Req        Request
+	Resp       ResponseWriter
+	params     Params
+	paramTypes map[string]ParamType
+	pattern    string
+	requestID  string
 	Render
 	Locale
 	Data map[string]interface{}
+
+	// resp and dummyRender are reused by pooled contexts for Resp and Render.
+	resp        responseWriter
+	dummyRender DummyRender
 }
 
 func (ctx *Context) handler() Handler {
@@ -155,6 +166,23 @@ func (ctx *Context) RemoteAddr() string {
 	return addr
 }
 
+// RoutePattern returns the pattern of the route that matched the request, e.g. "/user/:id",
+// or an empty string if no route matched.
+func (ctx *Context) RoutePattern() string {
+	return ctx.pattern
+}
+
+// RequestID returns the ID of current request set by the RequestID middleware,
+// or an empty string if the middleware is not used. When the Macaron instance uses
+// the middleware but it has not run yet, e.g. for Logger used before it, the ID is
+// set by the middleware at the first call.
+func (ctx *Context) RequestID() string {
+	if len(ctx.requestID) == 0 && ctx.Router != nil && ctx.m != nil && ctx.m.requestID != nil {
+		ctx.m.requestID(ctx)
+	}
+	return ctx.requestID
+}
+
 func (ctx *Context) renderHTML(status int, setName, tplName string, data ...interface{}) {
 	if len(data) <= 0 {
 		ctx.Render.HTMLSet(status, setName, tplName, ctx.Data)
@@ -176,6 +204,35 @@ func (ctx *Context) HTMLSet(status int, setName, tplName string, data ...interfa
 }
 
 // Redirect sends a redirect response
+// JSONP renders v as JSON wrapped by given callback function, see TplRender.JSONP.
+// It responds with 500 when the Render does not implement MediaRender.
+func (ctx *Context) JSONP(status int, callback string, v interface{}) {
+	if r, ok := ctx.Render.(MediaRender); ok {
+		r.JSONP(status, callback, v)
+		return
+	}
+	http.Error(ctx.Resp, "render does not support JSONP", http.StatusInternalServerError)
+}
+
+// Encode renders v by the encoder registered for given media type, see TplRender.Encode.
+// It responds with 500 when the Render does not implement MediaRender.
+func (ctx *Context) Encode(status int, mediaType string, v interface{}) {
+	if r, ok := ctx.Render.(MediaRender); ok {
+		r.Encode(status, mediaType, v)
+		return
+	}
+	http.Error(ctx.Resp, "render does not support media type "+mediaType, http.StatusInternalServerError)
+}
+
+// MediaTypes returns media types of encoders registered to the Render, or nil when
+// the Render does not implement MediaRender.
+func (ctx *Context) MediaTypes() []string {
+	if r, ok := ctx.Render.(MediaRender); ok {
+		return r.MediaTypes()
+	}
+	return nil
+}
+
 func (ctx *Context) Redirect(location string, status ...int) {
 	code := http.StatusFound
 	if len(status) == 1 {
@@ -264,6 +321,20 @@ func (ctx *Context) Params(name string) string {
 	return ctx.params[name]
 }
 
+// ParamValue returns value of param with given name converted by its param type declared
+// in the route pattern, e.g. an int for "/:id:int". It returns the raw string if the param
+// has no type or its type has no converter.
+func (ctx *Context) ParamValue(name string) (interface{}, error) {
+	if len(name) > 1 && name[0] != ':' {
+		name = ":" + name
+	}
+	value := ctx.Params(name)
+	if typ, ok := ctx.paramTypes[name]; ok && typ.Convert != nil {
+		return typ.Convert(value)
+	}
+	return value, nil
+}
+
 // AllParams returns all params.
 func (ctx *Context) AllParams() Params {
 	return ctx.params
@@ -274,6 +345,9 @@ func (ctx *Context) SetParams(name, val string) {
 	if name != "*" && !strings.HasPrefix(name, ":") {
 		name = ":" + name
 	}
+	if ctx.params == nil {
+		ctx.params = make(Params)
+	}
 	ctx.params[name] = val
 }
 
@@ -174,6 +174,37 @@ func Test_Context(t *testing.T) {
 			So(resp.Body.String(), ShouldEqual, "user user 1 13 1.24 ")
 		})
 
+		Convey("Typed URL parameter", func() {
+			m.Get("/:id:int/:day:date/:name/:slug:slug", func(ctx *Context) string {
+				id, err := ctx.ParamValue("id")
+				So(err, ShouldBeNil)
+				So(id, ShouldEqual, 42)
+				day, err := ctx.ParamValue(":day")
+				So(err, ShouldBeNil)
+				So(day, ShouldEqual, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC))
+				name, err := ctx.ParamValue("name")
+				So(err, ShouldBeNil)
+				So(name, ShouldEqual, "unknwon")
+				slug, err := ctx.ParamValue("slug")
+				So(err, ShouldBeNil)
+				So(slug, ShouldEqual, "hello-world")
+				return "ok"
+			})
+			m.Get("/day/:day:date", func() string { return "day" })
+
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/42/2026-01-02/unknwon/hello-world", nil)
+			So(err, ShouldBeNil)
+			m.ServeHTTP(resp, req)
+			So(resp.Body.String(), ShouldEqual, "ok")
+
+			resp = httptest.NewRecorder()
+			req, err = http.NewRequest("GET", "/day/2026-13-01", nil)
+			So(err, ShouldBeNil)
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusNotFound)
+		})
+
 		Convey("Get all URL paramaters", func() {
 			m.Get("/:arg/:param/:flag", func(ctx *Context) string {
 				kvs := make([]string, 0, len(ctx.AllParams()))
@@ -0,0 +1,582 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	_CONTENT_YAML    = "application/yaml"
+	_CONTENT_CSV     = "text/csv"
+	_CONTENT_NDJSON  = "application/x-ndjson"
+	_CONTENT_MSGPACK = "application/msgpack"
+	_CONTENT_JSONP   = "application/javascript"
+)
+
+// BinaryEncoder is an Encoder of binary format, its Content-Type is rendered without charset.
+type BinaryEncoder interface {
+	Encoder
+	IsBinary() bool
+}
+
+// defaultEncoders returns built-in encoders by media types.
+func defaultEncoders() map[string]Encoder {
+	return map[string]Encoder{
+		_CONTENT_YAML:    YAMLEncoder{},
+		_CONTENT_CSV:     CSVEncoder{},
+		_CONTENT_NDJSON:  NDJSONEncoder{},
+		_CONTENT_MSGPACK: MsgPackEncoder{},
+	}
+}
+
+// structField represents an exported field of struct with its encoding name.
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// structFields returns exported fields of given struct type, names are taken from the first
+// non-empty tag of given keys, or the field name converted by nameFn. Fields of anonymous
+// struct without a tag are promoted.
+func structFields(typ reflect.Type, nameFn func(string) string, keys ...string) []structField {
+	fields := make([]structField, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		var tag string
+		for _, key := range keys {
+			if tag = field.Tag.Get(key); len(tag) > 0 {
+				break
+			}
+		}
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && len(name) == 0 && indirectType(field.Type).Kind() == reflect.Struct {
+			for _, f := range structFields(indirectType(field.Type), nameFn, keys...) {
+				f.index = append([]int{i}, f.index...)
+				fields = append(fields, f)
+			}
+			continue
+		} else if !field.IsExported() {
+			continue
+		}
+
+		if len(name) == 0 {
+			name = nameFn(field.Name)
+		}
+		fields = append(fields, structField{name, []int{i}, strings.Contains(opts, "omitempty")})
+	}
+	return fields
+}
+
+// fieldByIndex returns the nested field of v, it returns an invalid value when any of
+// embedded pointers is nil.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// indirect dereferences pointers and interfaces until a concrete value.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func sameName(name string) string {
+	return name
+}
+
+// YAMLEncoder encodes values in YAML block style. Struct fields are named by the
+// "yaml" tag or the lowercased field name, and the "omitempty" option is supported.
+type YAMLEncoder struct{}
+
+func (YAMLEncoder) Encode(w io.Writer, v interface{}, _ *RenderOptions) error {
+	buf := new(bytes.Buffer)
+	if err := encodeYAML(buf, reflect.ValueOf(v), 0); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// yamlScalar returns the YAML representation of v if it is a scalar.
+func yamlScalar(v reflect.Value) (string, bool) {
+	if !v.IsValid() {
+		return "null", true
+	}
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "null", true
+		}
+		return yamlScalar(v.Elem())
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano), true
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsNaN(f):
+			return ".nan", true
+		case math.IsInf(f, 1):
+			return ".inf", true
+		case math.IsInf(f, -1):
+			return "-.inf", true
+		}
+		return strconv.FormatFloat(f, 'g', -1, v.Type().Bits()), true
+	case reflect.String:
+		return yamlString(v.String()), true
+	case reflect.Slice:
+		if v.IsNil() {
+			return "null", true
+		} else if v.Len() == 0 {
+			return "[]", true
+		}
+	case reflect.Array:
+		if v.Len() == 0 {
+			return "[]", true
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return "null", true
+		} else if v.Len() == 0 {
+			return "{}", true
+		}
+	}
+	return "", false
+}
+
+// yamlString returns s as a plain scalar if possible, or a double-quoted one otherwise.
+func yamlString(s string) string {
+	if len(s) == 0 || strings.TrimSpace(s) != s || strings.ContainsAny(s, "\n\r\t\"\\") ||
+		strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'%@`") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return strconv.Quote(s)
+	}
+
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+func yamlName(name string) string {
+	return strings.ToLower(name)
+}
+
+// encodeYAML writes v at given indentation, every line is terminated by a newline.
+func encodeYAML(buf *bytes.Buffer, v reflect.Value, indent int) error {
+	if scalar, ok := yamlScalar(v); ok {
+		buf.WriteString(strings.Repeat(" ", indent) + scalar + "\n")
+		return nil
+	}
+	v = indirect(v)
+
+	type entry struct {
+		key string
+		val reflect.Value
+	}
+	var entries []entry
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			item := new(bytes.Buffer)
+			if err := encodeYAML(item, v.Index(i), indent+2); err != nil {
+				return err
+			}
+			// Replace indentation of the first line with the sequence indicator.
+			buf.WriteString(strings.Repeat(" ", indent) + "- ")
+			buf.Write(item.Bytes()[indent+2:])
+		}
+		return nil
+	case reflect.Map:
+		keys := v.MapKeys()
+		entries = make([]entry, len(keys))
+		for i := range keys {
+			entries[i] = entry{fmt.Sprint(keys[i].Interface()), v.MapIndex(keys[i])}
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	case reflect.Struct:
+		for _, f := range structFields(v.Type(), yamlName, "yaml") {
+			fv := fieldByIndex(v, f.index)
+			if !fv.IsValid() || (f.omitEmpty && isZero(fv)) {
+				continue
+			}
+			entries = append(entries, entry{f.name, fv})
+		}
+		if len(entries) == 0 {
+			buf.WriteString(strings.Repeat(" ", indent) + "{}\n")
+			return nil
+		}
+	default:
+		return fmt.Errorf("yaml: unsupported type %s", v.Type())
+	}
+
+	for _, e := range entries {
+		buf.WriteString(strings.Repeat(" ", indent) + yamlString(e.key) + ":")
+		if scalar, ok := yamlScalar(e.val); ok {
+			buf.WriteString(" " + scalar + "\n")
+			continue
+		}
+		buf.WriteString("\n")
+
+		nested := indent + 2
+		if k := indirect(e.val).Kind(); k == reflect.Slice || k == reflect.Array {
+			nested = indent // Sequences in mappings are not indented.
+		}
+		if err := encodeYAML(buf, e.val, nested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CSVEncoder encodes a slice of structs in CSV with a header row, columns are named by
+// the "csv" tag or the field name. A single struct and [][]string are accepted as well.
+type CSVEncoder struct {
+	// Comma is the field delimiter. Default is ','.
+	Comma rune
+}
+
+func (e CSVEncoder) Encode(w io.Writer, v interface{}, _ *RenderOptions) error {
+	cw := csv.NewWriter(w)
+	if e.Comma != 0 {
+		cw.Comma = e.Comma
+	}
+
+	if records, ok := v.([][]string); ok {
+		if err := cw.WriteAll(records); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return fmt.Errorf("csv: unsupported value %v", v)
+	}
+	rows := []reflect.Value{rv}
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		rows = make([]reflect.Value, rv.Len())
+		for i := range rows {
+			rows[i] = indirect(rv.Index(i))
+		}
+	}
+
+	elemType := rv.Type()
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		elemType = indirectType(elemType.Elem())
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("csv: unsupported type %s", rv.Type())
+	}
+
+	fields := structFields(elemType, sameName, "csv")
+	record := make([]string, len(fields))
+	for i := range fields {
+		record[i] = fields[i].name
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if row.Kind() != reflect.Struct {
+			continue // Nil pointers.
+		}
+		for i := range fields {
+			record[i] = ""
+			fv := indirect(fieldByIndex(row, fields[i].index))
+			if !fv.IsValid() || ((fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface) && fv.IsNil()) {
+				continue
+			}
+			if t, ok := fv.Interface().(time.Time); ok {
+				record[i] = t.Format(time.RFC3339)
+			} else {
+				record[i] = fmt.Sprint(fv.Interface())
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// NDJSONEncoder encodes each element of a slice as a line of JSON,
+// other values are encoded as a single line. RenderOptions.PrefixJSON is written
+// before the lines, but IndentJSON is ignored since each value must be on a line.
+type NDJSONEncoder struct{}
+
+func (NDJSONEncoder) Encode(w io.Writer, v interface{}, opt *RenderOptions) error {
+	if opt != nil && len(opt.PrefixJSON) > 0 {
+		if _, err := w.Write(opt.PrefixJSON); err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	rv := indirect(reflect.ValueOf(v))
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || isByteSlice(rv) {
+		return enc.Encode(v)
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MsgPackEncoder encodes values in MessagePack. Structs are encoded as maps with keys named
+// by the "msgpack" tag or the field name, and time.Time is encoded as RFC 3339 string.
+type MsgPackEncoder struct{}
+
+func (MsgPackEncoder) Encode(w io.Writer, v interface{}, _ *RenderOptions) error {
+	buf := new(bytes.Buffer)
+	if err := encodeMsgPack(buf, reflect.ValueOf(v)); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func (MsgPackEncoder) IsBinary() bool {
+	return true
+}
+
+// writeMsgPackHeader writes the header of a variable length type, the fix
+// format is used when n fits in fixMax, then 8-bit (if code8 is not 0), 16-bit
+// and 32-bit formats are used in turn.
+func writeMsgPackHeader(buf *bytes.Buffer, n int, fixCode byte, fixMax int, code8, code16, code32 byte) error {
+	switch {
+	case n <= fixMax:
+		buf.WriteByte(fixCode | byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		buf.Write([]byte{code8, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	case uint64(n) <= math.MaxUint32:
+		buf.WriteByte(code32)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		return fmt.Errorf("msgpack: length %d is too large", n)
+	}
+	return nil
+}
+
+func encodeMsgPackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0:
+		encodeMsgPackUint(buf, uint64(i))
+	case i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		buf.Write([]byte{0xd0, byte(i)})
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		_ = binary.Write(buf, binary.BigEndian, int16(i))
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		_ = binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		_ = binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+func encodeMsgPackUint(buf *bytes.Buffer, u uint64) {
+	switch {
+	case u <= 0x7f:
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		buf.Write([]byte{0xcc, byte(u)})
+	case u <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		_ = binary.Write(buf, binary.BigEndian, uint16(u))
+	case u <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		_ = binary.Write(buf, binary.BigEndian, uint32(u))
+	default:
+		buf.WriteByte(0xcf)
+		_ = binary.Write(buf, binary.BigEndian, u)
+	}
+}
+
+func encodeMsgPackString(buf *bytes.Buffer, s string) error {
+	if err := writeMsgPackHeader(buf, len(s), 0xa0, 31, 0xd9, 0xda, 0xdb); err != nil {
+		return err
+	}
+	buf.WriteString(s)
+	return nil
+}
+
+func encodeMsgPack(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return encodeMsgPackString(buf, t.Format(time.RFC3339Nano))
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		return encodeMsgPack(buf, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		encodeMsgPackInt(buf, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		encodeMsgPackUint(buf, v.Uint())
+	case reflect.Float32:
+		buf.WriteByte(0xca)
+		_ = binary.Write(buf, binary.BigEndian, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		buf.WriteByte(0xcb)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(v.Float()))
+	case reflect.String:
+		return encodeMsgPackString(buf, v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if err := writeMsgPackHeader(buf, v.Len(), 0, -1, 0xc4, 0xc5, 0xc6); err != nil {
+				return err
+			}
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			buf.Write(b)
+			return nil
+		}
+
+		if err := writeMsgPackHeader(buf, v.Len(), 0x90, 15, 0, 0xdc, 0xdd); err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeMsgPack(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		if err := writeMsgPackHeader(buf, len(keys), 0x80, 15, 0, 0xde, 0xdf); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := encodeMsgPack(buf, key); err != nil {
+				return err
+			}
+			if err := encodeMsgPack(buf, v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		fields := structFields(v.Type(), sameName, "msgpack")
+		vals := make([]reflect.Value, 0, len(fields))
+		names := make([]string, 0, len(fields))
+		for _, f := range fields {
+			fv := fieldByIndex(v, f.index)
+			if !fv.IsValid() || (f.omitEmpty && isZero(fv)) {
+				continue
+			}
+			names = append(names, f.name)
+			vals = append(vals, fv)
+		}
+		if err := writeMsgPackHeader(buf, len(vals), 0x80, 15, 0, 0xde, 0xdf); err != nil {
+			return err
+		}
+		for i := range vals {
+			if err := encodeMsgPackString(buf, names[i]); err != nil {
+				return err
+			}
+			if err := encodeMsgPack(buf, vals[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
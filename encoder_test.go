@@ -0,0 +1,236 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type encoderMeta struct {
+	Created time.Time `yaml:"created" csv:"created"`
+}
+
+type encoderItem struct {
+	encoderMeta
+	Name   string            `yaml:"name" csv:"name" msgpack:"name"`
+	Count  int               `yaml:"count,omitempty" csv:"count" msgpack:"count"`
+	Tags   []string          `yaml:"tags" csv:"-" msgpack:"-"`
+	Labels map[string]string `yaml:"labels,omitempty" csv:"-" msgpack:"-"`
+	secret string
+}
+
+func encode(enc Encoder, v interface{}) string {
+	var buf bytes.Buffer
+	So(enc.Encode(&buf, v, nil), ShouldBeNil)
+	return buf.String()
+}
+
+func Test_YAMLEncoder(t *testing.T) {
+	Convey("Encode values in YAML", t, func() {
+		created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		items := []encoderItem{
+			{encoderMeta{created}, "first: one", 1, []string{"a", "true"}, map[string]string{"k": "v"}, ""},
+			{encoderMeta{created}, "second", 0, nil, nil, ""},
+		}
+		So(encode(YAMLEncoder{}, items), ShouldEqual, `- created: 2026-01-02T03:04:05Z
+  name: "first: one"
+  count: 1
+  tags:
+  - a
+  - "true"
+  labels:
+    k: v
+- created: 2026-01-02T03:04:05Z
+  name: second
+  tags: null
+`)
+
+		So(encode(YAMLEncoder{}, map[string]interface{}{
+			"nested": [][]int{{1, 2}, {}},
+			"empty":  map[string]int{},
+			"float":  1.5,
+			"nil":    nil,
+		}), ShouldEqual, `empty: {}
+float: 1.5
+nested:
+- - 1
+  - 2
+- []
+nil: null
+`)
+		So(encode(YAMLEncoder{}, "123"), ShouldEqual, "\"123\"\n")
+		So(YAMLEncoder{}.Encode(&bytes.Buffer{}, map[string]interface{}{"fn": func() {}}, nil), ShouldNotBeNil)
+	})
+}
+
+func Test_CSVEncoder(t *testing.T) {
+	Convey("Encode values in CSV", t, func() {
+		created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		items := []*encoderItem{
+			{encoderMeta: encoderMeta{created}, Name: "first, one", Count: 1},
+			nil,
+			{encoderMeta: encoderMeta{created}, Name: "second"},
+		}
+		So(encode(CSVEncoder{}, items), ShouldEqual, `created,name,count
+2026-01-02T03:04:05Z,"first, one",1
+2026-01-02T03:04:05Z,second,0
+`)
+		So(encode(CSVEncoder{Comma: ';'}, encoderItem{Name: "single"}), ShouldEqual, "created;name;count\n0001-01-01T00:00:00Z;single;0\n")
+		So(encode(CSVEncoder{}, [][]string{{"a", "b"}, {"1", "2"}}), ShouldEqual, "a,b\n1,2\n")
+		So(CSVEncoder{}.Encode(&bytes.Buffer{}, []int{1}, nil), ShouldNotBeNil)
+	})
+}
+
+func Test_NDJSONEncoder(t *testing.T) {
+	Convey("Encode values in NDJSON", t, func() {
+		So(encode(NDJSONEncoder{}, []Greeting{{"a", "b"}, {"c", "d"}}), ShouldEqual, `{"one":"a","two":"b"}
+{"one":"c","two":"d"}
+`)
+		So(encode(NDJSONEncoder{}, Greeting{"a", "b"}), ShouldEqual, "{\"one\":\"a\",\"two\":\"b\"}\n")
+	})
+}
+
+func Test_MsgPackEncoder(t *testing.T) {
+	Convey("Encode values in MessagePack", t, func() {
+		tests := []struct {
+			in  interface{}
+			out []byte
+		}{
+			{nil, []byte{0xc0}},
+			{true, []byte{0xc3}},
+			{false, []byte{0xc2}},
+			{1, []byte{0x01}},
+			{-1, []byte{0xff}},
+			{-100, []byte{0xd0, 0x9c}},
+			{200, []byte{0xcc, 0xc8}},
+			{70000, []byte{0xce, 0x00, 0x01, 0x11, 0x70}},
+			{-40000, []byte{0xd2, 0xff, 0xff, 0x63, 0xc0}},
+			{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+			{float32(1.5), []byte{0xca, 0x3f, 0xc0, 0, 0}},
+			{"abc", []byte{0xa3, 'a', 'b', 'c'}},
+			{[]byte{1, 2}, []byte{0xc4, 0x02, 0x01, 0x02}},
+			{[]int{1, 2}, []byte{0x92, 0x01, 0x02}},
+			{map[string]int{"b": 2, "a": 1}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+			{encoderItem{Name: "x", Count: 3}, []byte{
+				0x83,
+				0xa7, 'C', 'r', 'e', 'a', 't', 'e', 'd', 0xb4,
+				'0', '0', '0', '1', '-', '0', '1', '-', '0', '1', 'T', '0', '0', ':', '0', '0', ':', '0', '0', 'Z',
+				0xa4, 'n', 'a', 'm', 'e', 0xa1, 'x',
+				0xa5, 'c', 'o', 'u', 'n', 't', 0x03,
+			}},
+		}
+		for _, test := range tests {
+			So([]byte(encode(MsgPackEncoder{}, test.in)), ShouldResemble, test.out)
+		}
+
+		long := make([]byte, 40)
+		for i := range long {
+			long[i] = 'x'
+		}
+		So([]byte(encode(MsgPackEncoder{}, string(long)))[:2], ShouldResemble, []byte{0xd9, 40})
+		So(MsgPackEncoder{}.Encode(&bytes.Buffer{}, make(chan int), nil), ShouldNotBeNil)
+	})
+}
+
+func Test_Render_Encoders(t *testing.T) {
+	Convey("Render with built-in encoders", t, func() {
+		m := New()
+		m.Use(Renderer(RenderOptions{Charset: "foobar"}))
+		m.Get("/:type", func(ctx *Context) {
+			ctx.Encode(http.StatusOK, map[string]string{
+				"yaml":    _CONTENT_YAML,
+				"msgpack": _CONTENT_MSGPACK,
+			}[ctx.Params("type")], []int{1})
+		})
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/yaml", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldEqual, _CONTENT_YAML+"; charset=foobar")
+		So(resp.Body.String(), ShouldEqual, "- 1\n")
+
+		resp = httptest.NewRecorder()
+		req, err = http.NewRequest("GET", "/msgpack", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldEqual, _CONTENT_MSGPACK)
+		So(resp.Body.Bytes(), ShouldResemble, []byte{0x91, 0x01})
+	})
+
+	Convey("Render with options passed to encoders", t, func() {
+		m := New()
+		m.Use(Renderer(RenderOptions{
+			IndentJSON: true,
+			PrefixJSON: []byte(")]}',\n"),
+			Encoders: map[string]Encoder{
+				"application/vnd.example+json": EncoderFunc(func(w io.Writer, v interface{}, opt *RenderOptions) error {
+					enc := json.NewEncoder(w)
+					if opt.IndentJSON {
+						enc.SetIndent("", "  ")
+					}
+					return enc.Encode(v)
+				}),
+			},
+		}))
+		m.Get("/ndjson", func(ctx *Context) {
+			ctx.Encode(http.StatusOK, _CONTENT_NDJSON, []Greeting{{"a", "b"}, {"c", "d"}})
+		})
+		m.Get("/custom", func(ctx *Context) {
+			ctx.Encode(http.StatusOK, "application/vnd.example+json", Greeting{"a", "b"})
+		})
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/ndjson", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Body.String(), ShouldEqual, ")]}',\n{\"one\":\"a\",\"two\":\"b\"}\n{\"one\":\"c\",\"two\":\"d\"}\n")
+
+		resp = httptest.NewRecorder()
+		req, err = http.NewRequest("GET", "/custom", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Body.String(), ShouldEqual, "{\n  \"one\": \"a\",\n  \"two\": \"b\"\n}\n")
+	})
+
+	Convey("Render JSONP", t, func() {
+		m := New()
+		m.Use(Renderer())
+		m.Get("/", func(ctx *Context) {
+			ctx.JSONP(http.StatusOK, ctx.Query("callback"), Greeting{"hello", "world"})
+		})
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/?callback=jQuery.cb_1", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldEqual, _CONTENT_JSONP+"; charset=UTF-8")
+		So(resp.Body.String(), ShouldEqual, `/**/jQuery.cb_1({"one":"hello","two":"world"});`)
+
+		resp = httptest.NewRecorder()
+		req, err = http.NewRequest("GET", "/?callback=alert(1)//", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusBadRequest)
+	})
+}
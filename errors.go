@@ -0,0 +1,188 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// HTTPError is an error that carries the HTTP status to respond with. Route handlers can return it
+// to respond with a status other than 500, e.g. return macaron.NewHTTPError(404, "user not found").
+type HTTPError struct {
+	// Status is the HTTP status code.
+	Status int
+	// Code is an optional application-specific error code.
+	Code string
+	// Message is the message safe to show to clients.
+	Message string
+	// Details is optional additional information for clients, e.g. invalid fields.
+	Details interface{}
+	// Err is the underlying error, which is not shown to clients.
+	Err error
+}
+
+// NewHTTPError creates a new HTTPError with given status and message.
+// The message defaults to the status text.
+func NewHTTPError(status int, message ...string) *HTTPError {
+	e := &HTTPError{
+		Status:  status,
+		Message: http.StatusText(status),
+	}
+	if len(message) > 0 {
+		e.Message = message[0]
+	}
+	return e
+}
+
+// WithCode sets the application-specific error code and returns the error itself.
+func (e *HTTPError) WithCode(code string) *HTTPError {
+	e.Code = code
+	return e
+}
+
+// WithDetails sets the details and returns the error itself.
+func (e *HTTPError) WithDetails(details interface{}) *HTTPError {
+	e.Details = details
+	return e
+}
+
+// Wrap sets the underlying error and returns the error itself.
+func (e *HTTPError) Wrap(err error) *HTTPError {
+	e.Err = err
+	return e
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// Problem returns the problem details of the error.
+func (e *HTTPError) Problem() *Problem {
+	p := &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(e.Status),
+		Status: e.Status,
+		Detail: e.Message,
+	}
+	if len(e.Code) > 0 || e.Details != nil {
+		p.Extensions = make(map[string]interface{}, 2)
+		if len(e.Code) > 0 {
+			p.Extensions["code"] = e.Code
+		}
+		if e.Details != nil {
+			p.Extensions["details"] = e.Details
+		}
+	}
+	return p
+}
+
+// ErrorStatus returns the status of the HTTPError in the chain of err, or 500 if there is none.
+func ErrorStatus(err error) int {
+	var e *HTTPError
+	if errors.As(err, &e) && e.Status > 0 {
+		return e.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// Problem is a problem details object of RFC 7807.
+type Problem struct {
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+	// Extensions are additional members of the object.
+	Extensions map[string]interface{}
+}
+
+// MarshalJSON encodes the problem with extension members, members with empty values are omitted.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	for k, v := range map[string]string{
+		"type":     p.Type,
+		"title":    p.Title,
+		"detail":   p.Detail,
+		"instance": p.Instance,
+	} {
+		if len(v) > 0 {
+			m[k] = v
+		}
+	}
+	if p.Status > 0 {
+		m["status"] = p.Status
+	}
+	return json.Marshal(m)
+}
+
+// ErrorProblem returns the problem details of err. The message of err is only shown
+// in development mode unless it is an HTTPError.
+func ErrorProblem(err error) *Problem {
+	var e *HTTPError
+	if errors.As(err, &e) {
+		return e.Problem()
+	}
+
+	p := &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+	}
+	if Env == DEV {
+		p.Detail = err.Error()
+	}
+	return p
+}
+
+// writeProblem writes the problem as application/problem+json with its status.
+func writeProblem(rw http.ResponseWriter, p *Problem) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := p.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	rw.Header().Set(_CONTENT_TYPE, _CONTENT_PROBLEM_JSON)
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+	rw.WriteHeader(status)
+	_, _ = rw.Write(body)
+}
+
+// Problem responds with the problem details as application/problem+json with its status.
+func (ctx *Context) Problem(p *Problem) {
+	writeProblem(ctx.Resp, p)
+}
+
+// ProblemErrorHandler is a handler for Router.InternalServerError that responds with errors
+// returned by route handlers as application/problem+json.
+func ProblemErrorHandler(rw http.ResponseWriter, err error) {
+	writeProblem(rw, ErrorProblem(err))
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_HTTPError(t *testing.T) {
+	Convey("Create HTTP errors", t, func() {
+		err := NewHTTPError(http.StatusNotFound)
+		So(err.Error(), ShouldEqual, "Not Found")
+		So(ErrorStatus(err), ShouldEqual, http.StatusNotFound)
+
+		cause := errors.New("no rows")
+		err = NewHTTPError(http.StatusConflict, "user exists").WithCode("USER_EXISTS").Wrap(cause)
+		So(err.Error(), ShouldEqual, "user exists: no rows")
+		So(errors.Is(err, cause), ShouldBeTrue)
+		So(ErrorStatus(fmt.Errorf("create: %w", err)), ShouldEqual, http.StatusConflict)
+		So(ErrorStatus(cause), ShouldEqual, http.StatusInternalServerError)
+	})
+
+	Convey("Respond with status of returned HTTP errors", t, func() {
+		m := New()
+		m.Get("/404", func() error {
+			return NewHTTPError(http.StatusNotFound, "user not found").Wrap(errors.New("secret"))
+		})
+		m.Get("/500", func() error {
+			return errors.New("boom")
+		})
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/404", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusNotFound)
+		So(resp.Body.String(), ShouldEqual, "user not found\n")
+
+		resp = httptest.NewRecorder()
+		req, err = http.NewRequest("GET", "/500", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusInternalServerError)
+		So(resp.Body.String(), ShouldEqual, "boom\n")
+	})
+}
+
+func Test_Problem(t *testing.T) {
+	Convey("Respond with problem details", t, func() {
+		m := New()
+		m.InternalServerError(ProblemErrorHandler)
+		m.Get("/422", func() error {
+			return NewHTTPError(http.StatusUnprocessableEntity, "invalid user").
+				WithCode("INVALID_USER").
+				WithDetails(map[string]string{"name": "required"})
+		})
+		m.Get("/500", func() error {
+			return errors.New("boom")
+		})
+		m.Get("/problem", func(ctx *Context) {
+			ctx.Problem(&Problem{
+				Type:       "https://example.com/probs/out-of-credit",
+				Title:      "You do not have enough credit.",
+				Status:     http.StatusForbidden,
+				Instance:   "/account/12345",
+				Extensions: map[string]interface{}{"balance": 30},
+			})
+		})
+		request := func(url string) *httptest.ResponseRecorder {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", url, nil)
+			So(err, ShouldBeNil)
+			m.ServeHTTP(resp, req)
+			return resp
+		}
+
+		resp := request("/422")
+		So(resp.Code, ShouldEqual, http.StatusUnprocessableEntity)
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldEqual, _CONTENT_PROBLEM_JSON)
+		So(resp.Body.String(), ShouldEqual, `{"code":"INVALID_USER","detail":"invalid user","details":{"name":"required"},"status":422,"title":"Unprocessable Entity","type":"about:blank"}`)
+
+		resp = request("/500")
+		So(resp.Code, ShouldEqual, http.StatusInternalServerError)
+		So(resp.Body.String(), ShouldEqual, `{"detail":"boom","status":500,"title":"Internal Server Error","type":"about:blank"}`)
+
+		setENV(PROD)
+		defer setENV(DEV)
+		resp = request("/500")
+		So(resp.Body.String(), ShouldEqual, `{"status":500,"title":"Internal Server Error","type":"about:blank"}`)
+
+		resp = request("/problem")
+		So(resp.Code, ShouldEqual, http.StatusForbidden)
+		So(resp.Body.String(), ShouldEqual, `{"balance":30,"instance":"/account/12345","status":403,"title":"You do not have enough credit.","type":"https://example.com/probs/out-of-credit"}`)
+	})
+}
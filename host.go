@@ -0,0 +1,163 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// hostRouter represents the route table of routes constrained by a host pattern.
+type hostRouter struct {
+	pattern    string
+	reg        *regexp.Regexp // It is nil when the pattern is a static host.
+	wildcards  []string
+	paramTypes map[string]ParamType
+
+	routers map[string]*Tree
+	*routeMap
+}
+
+// newHostRouter creates the route table for given host pattern, which consists of labels
+// separated by dots. A label can be static, a wildcard like ":tenant" which matches a single
+// label and is captured as param, optionally with a param type like ":id:int", or "*" which
+// matches one or more labels.
+func newHostRouter(pattern string) *hostRouter {
+	pattern = strings.ToLower(pattern)
+	hr := &hostRouter{
+		pattern:    pattern,
+		paramTypes: getParamTypes(pattern),
+		routers:    make(map[string]*Tree),
+		routeMap:   NewRouteMap(),
+	}
+	if !strings.ContainsAny(pattern, ":*") {
+		return hr
+	}
+
+	var buf strings.Builder
+	buf.WriteString("^")
+	for i, label := range strings.Split(pattern, ".") {
+		if i > 0 {
+			buf.WriteString(`\.`)
+		}
+
+		if label == "*" {
+			buf.WriteString(`.+`)
+			continue
+		} else if len(label) < 2 || label[0] != ':' {
+			buf.WriteString(regexp.QuoteMeta(label))
+			continue
+		}
+
+		wildcard := wildcardPattern.FindString(label)
+		if !strings.HasPrefix(label, wildcard) {
+			panic("invalid label of host pattern: " + label)
+		}
+		expr := `[^.]+`
+		if typ := label[len(wildcard):]; len(typ) > 0 {
+			name, paramType, ok := paramTypeAt(typ)
+			if !ok || name != typ {
+				panic("unknown param type of host pattern: " + label)
+			}
+			expr = paramType.Pattern
+		}
+		buf.WriteString("(" + expr + ")")
+		hr.wildcards = append(hr.wildcards, wildcard)
+	}
+	buf.WriteString("$")
+	hr.reg = regexp.MustCompile(buf.String())
+	return hr
+}
+
+// match returns params captured from given host, and false if the host does not match.
+func (hr *hostRouter) match(host string) (Params, bool) {
+	if hr.reg == nil {
+		return nil, host == hr.pattern
+	}
+
+	results := hr.reg.FindStringSubmatch(host)
+	if results == nil {
+		return nil, false
+	}
+	var params Params
+	for i := range hr.wildcards {
+		setParam(&params, hr.wildcards[i], results[i+1])
+	}
+	for name, typ := range hr.paramTypes {
+		if typ.Convert == nil {
+			continue
+		}
+		if _, err := typ.Convert(params[name]); err != nil {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// requestHost returns the lower-cased host of the request without port.
+func requestHost(req *http.Request) string {
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	return strings.ToLower(host)
+}
+
+// hostRouter returns the route table of given host pattern, a new one is created when it does not exist.
+// Route tables of static hosts are matched before the ones of host patterns with wildcards.
+func (r *Router) hostRouter(pattern string) *hostRouter {
+	pattern = strings.ToLower(pattern)
+	for _, hr := range r.hosts {
+		if hr.pattern == pattern {
+			return hr
+		}
+	}
+
+	hr := newHostRouter(pattern)
+	i := len(r.hosts)
+	if hr.reg == nil {
+		i = sort.Search(len(r.hosts), func(i int) bool { return r.hosts[i].reg != nil })
+	}
+	r.hosts = append(r.hosts, nil)
+	copy(r.hosts[i+1:], r.hosts[i:])
+	r.hosts[i] = hr
+	return hr
+}
+
+// Host registers routes added by fn to the route table of given host pattern, so that they are
+// only matched for requests to hosts matching the pattern, with given handlers like Group.
+// Params captured from the host are available by ctx.Params along with params of the path, e.g.
+//
+//	m.Host(":tenant.example.com", func() {
+//		m.Get("/", func(ctx *macaron.Context) string { return ctx.Params("tenant") })
+//	})
+//
+// Route tables of static hosts are matched first, then the ones of host patterns in the order
+// they are registered, and routes registered without host are matched at last.
+func (r *Router) Host(pattern string, fn func(), h ...Handler) {
+	if r.host != nil {
+		panic("host groups cannot be nested")
+	}
+
+	r.host = r.hostRouter(pattern)
+	r.groups = append(r.groups, group{"", h})
+	fn()
+	r.groups = r.groups[:len(r.groups)-1]
+	r.host = nil
+}
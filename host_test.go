@@ -0,0 +1,124 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_hostRouter(t *testing.T) {
+	Convey("Match hosts", t, func() {
+		cases := []struct {
+			pattern, host string
+			params        Params
+			ok            bool
+		}{
+			{"admin.example.com", "admin.example.com", nil, true},
+			{"Admin.Example.com", "admin.example.com", nil, true},
+			{"admin.example.com", "www.example.com", nil, false},
+			{":tenant.example.com", "acme.example.com", Params{":tenant": "acme"}, true},
+			{":tenant.example.com", "a.b.example.com", nil, false},
+			{":tenant.:region.example.com", "acme.eu.example.com", Params{":tenant": "acme", ":region": "eu"}, true},
+			{":id:int.example.com", "42.example.com", Params{":id": "42"}, true},
+			{":id:int.example.com", "acme.example.com", nil, false},
+			{":id:int.example.com", "99999999999999999999.example.com", nil, false},
+			{"*.example.com", "a.b.example.com", nil, true},
+			{"*.example.com", "example.com", nil, false},
+		}
+		for _, c := range cases {
+			params, ok := newHostRouter(c.pattern).match(c.host)
+			So(ok, ShouldEqual, c.ok)
+			So(params, ShouldResemble, c.params)
+		}
+
+		So(func() { newHostRouter(":id:unknown.example.com") }, ShouldPanic)
+	})
+
+	Convey("Get host of requests", t, func() {
+		for host, expected := range map[string]string{
+			"example.com":      "example.com",
+			"Example.COM:8080": "example.com",
+			"example.com.":     "example.com",
+			"[::1]:8080":       "::1",
+			"[::1]":            "::1",
+		} {
+			So(requestHost(&http.Request{Host: host}), ShouldEqual, expected)
+		}
+	})
+}
+
+func Test_Router_Host(t *testing.T) {
+	Convey("Route requests by host", t, func() {
+		m := New()
+		m.SetHandleMethodNotAllowed(true)
+		m.Host(":tenant.example.com", func() {
+			m.Get("/", func(ctx *Context) string { return "tenant " + ctx.Params("tenant") })
+			m.Get("/users/:id", func(ctx *Context) string { return ctx.Params("tenant") + " user " + ctx.Params("id") })
+		}, func(ctx *Context) { ctx.Resp.Header().Set("X-Tenant", ctx.Params("tenant")) })
+		m.Host("admin.example.com", func() {
+			m.Group("/admin", func() {
+				m.Get("", func() string { return "admin" })
+			})
+			m.Post("/users/:id", func() string { return "admin user" })
+		})
+		m.Get("/", func() string { return "default" })
+		m.Get("/health", func() string { return "ok" })
+
+		cases := []struct {
+			host, method, url string
+			code              int
+			body, tenant      string
+		}{
+			{"acme.example.com", "GET", "/", http.StatusOK, "tenant acme", "acme"},
+			{"acme.example.com:8080", "GET", "/users/1", http.StatusOK, "acme user 1", "acme"},
+			{"acme.example.com", "GET", "/health", http.StatusOK, "ok", ""},
+			{"admin.example.com", "GET", "/admin", http.StatusOK, "admin", ""},
+			{"admin.example.com", "GET", "/", http.StatusOK, "tenant admin", "admin"},
+			{"admin.example.com", "POST", "/users/1", http.StatusOK, "admin user", ""},
+			{"acme.example.com", "GET", "/admin", http.StatusNotFound, "404 page not found\n", ""},
+			{"example.com", "GET", "/", http.StatusOK, "default", ""},
+			{"example.com", "GET", "/users/1", http.StatusNotFound, "404 page not found\n", ""},
+			{"acme.example.com", "POST", "/users/1", http.StatusMethodNotAllowed, "Method Not Allowed\n", ""},
+		}
+		for _, c := range cases {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest(c.method, c.url, nil)
+			So(err, ShouldBeNil)
+			req.Host = c.host
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, c.code)
+			So(resp.Body.String(), ShouldEqual, c.body)
+			So(resp.Header().Get("X-Tenant"), ShouldEqual, c.tenant)
+		}
+
+		Convey("List routes with hosts", func() {
+			routes := m.Routes()
+			So(routes, ShouldHaveLength, 6)
+			So(routes[2].Host, ShouldEqual, ":tenant.example.com")
+			So(routes[2].Groups, ShouldBeEmpty)
+			So(routes[4].Host, ShouldEqual, "admin.example.com")
+			So(routes[4].Pattern, ShouldEqual, "/admin")
+			So(routes[4].Groups, ShouldResemble, []string{"/admin"})
+		})
+
+		Convey("Register nested hosts", func() {
+			So(func() { m.Host("a.example.com", func() { m.Host("b.example.com", func() {}) }) }, ShouldPanic)
+		})
+	})
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-macaron/inject"
+)
+
+// injector is an inject.Injector that behaves the same as the one created by inject.New,
+// but it can be reset to be reused by pooled contexts, and it reuses buffers of arguments
+// for invocations.
+type injector struct {
+	values map[reflect.Type]reflect.Value
+	parent inject.Injector
+
+	// args is the stack of arguments of nested invocations, depth is its current size.
+	args  []interface{}
+	depth int
+}
+
+func newInjector() *injector {
+	return &injector{
+		values: make(map[reflect.Type]reflect.Value),
+	}
+}
+
+// reset removes all mapped values and the parent, while keeping allocated memory.
+func (inj *injector) reset() {
+	clear(inj.values)
+	clear(inj.args)
+	inj.parent = nil
+	inj.depth = 0
+}
+
+// Invoke implements inject.Invoker.
+func (inj *injector) Invoke(f interface{}) ([]reflect.Value, error) {
+	t := reflect.TypeOf(handlerFunc(f))
+	if v, ok := f.(inject.FastInvoker); ok {
+		return inj.fastInvoke(v, t, t.NumIn())
+	}
+	return inj.callInvoke(f, t, t.NumIn())
+}
+
+func (inj *injector) fastInvoke(f inject.FastInvoker, t reflect.Type, numIn int) ([]reflect.Value, error) {
+	var in []interface{}
+	if numIn > 0 {
+		// Arguments are taken from the stack, so that handlers calling ctx.Next
+		// do not have their arguments overwritten.
+		start := inj.depth
+		if len(inj.args) < start+numIn {
+			inj.args = append(inj.args, make([]interface{}, start+numIn-len(inj.args))...)
+		}
+		in = inj.args[start : start+numIn : start+numIn]
+		for i := 0; i < numIn; i++ {
+			argType := t.In(i)
+			val := inj.GetVal(argType)
+			if !val.IsValid() {
+				return nil, fmt.Errorf("Value not found for type %v", argType)
+			}
+			in[i] = val.Interface()
+		}
+
+		inj.depth += numIn
+		defer func() { inj.depth = start }()
+	}
+	return f.Invoke(in)
+}
+
+func (inj *injector) callInvoke(f interface{}, t reflect.Type, numIn int) ([]reflect.Value, error) {
+	var in []reflect.Value
+	if numIn > 0 {
+		in = make([]reflect.Value, numIn)
+		for i := 0; i < numIn; i++ {
+			argType := t.In(i)
+			val := inj.GetVal(argType)
+			if !val.IsValid() {
+				return nil, fmt.Errorf("Value not found for type %v", argType)
+			}
+			in[i] = val
+		}
+	}
+	return reflect.ValueOf(f).Call(in), nil
+}
+
+// Apply implements inject.Applicator.
+func (inj *injector) Apply(val interface{}) error {
+	v := reflect.ValueOf(val)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		structField := t.Field(i)
+		if f.CanSet() && (structField.Tag == "inject" || structField.Tag.Get("inject") != "") {
+			ft := f.Type()
+			v := inj.GetVal(ft)
+			if !v.IsValid() {
+				return fmt.Errorf("Value not found for type %v", ft)
+			}
+			f.Set(v)
+		}
+	}
+	return nil
+}
+
+// Map implements inject.TypeMapper.
+func (inj *injector) Map(val interface{}) inject.TypeMapper {
+	inj.values[reflect.TypeOf(val)] = reflect.ValueOf(val)
+	return inj
+}
+
+// MapTo implements inject.TypeMapper.
+func (inj *injector) MapTo(val interface{}, ifacePtr interface{}) inject.TypeMapper {
+	inj.values[inject.InterfaceOf(ifacePtr)] = reflect.ValueOf(val)
+	return inj
+}
+
+// Set implements inject.TypeMapper.
+func (inj *injector) Set(typ reflect.Type, val reflect.Value) inject.TypeMapper {
+	inj.values[typ] = val
+	return inj
+}
+
+// GetVal implements inject.TypeMapper.
+func (inj *injector) GetVal(t reflect.Type) reflect.Value {
+	val := inj.values[t]
+	if val.IsValid() {
+		return val
+	}
+
+	// No concrete types found, try to find implementors if t is an interface.
+	if t.Kind() == reflect.Interface {
+		for k, v := range inj.values {
+			if k.Implements(t) {
+				val = v
+				break
+			}
+		}
+	}
+
+	// Still no type found, try to look it up on the parent.
+	if !val.IsValid() && inj.parent != nil {
+		val = inj.parent.GetVal(t)
+	}
+	return val
+}
+
+// SetParent implements inject.Injector.
+func (inj *injector) SetParent(parent inject.Injector) {
+	inj.parent = parent
+}
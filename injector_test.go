@@ -0,0 +1,81 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/go-macaron/inject"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type injectorInvoker func(s string, n int)
+
+func (invoke injectorInvoker) Invoke(params []interface{}) ([]reflect.Value, error) {
+	invoke(params[0].(string), params[1].(int))
+	return nil, nil
+}
+
+func Test_Injector(t *testing.T) {
+	Convey("Map and get values", t, func() {
+		parent := inject.New()
+		parent.Map(1)
+
+		inj := newInjector()
+		inj.SetParent(parent)
+		inj.Map("value")
+		inj.MapTo(&bytes.Buffer{}, (*io.Writer)(nil))
+
+		So(inj.GetVal(reflect.TypeOf("")).String(), ShouldEqual, "value")
+		So(inj.GetVal(reflect.TypeOf(1)).Int(), ShouldEqual, 1)
+		So(inj.GetVal(reflect.TypeOf((*io.Writer)(nil)).Elem()).IsValid(), ShouldBeTrue)
+		So(inj.GetVal(reflect.TypeOf((*fmt.Stringer)(nil)).Elem()).IsValid(), ShouldBeFalse)
+
+		var s struct {
+			N int `inject:"t"`
+		}
+		So(inj.Apply(&s), ShouldBeNil)
+		So(s.N, ShouldEqual, 1)
+
+		inj.reset()
+		So(inj.GetVal(reflect.TypeOf("")).IsValid(), ShouldBeFalse)
+		So(inj.GetVal(reflect.TypeOf(1)).IsValid(), ShouldBeFalse)
+	})
+
+	Convey("Keep arguments of nested invocations", t, func() {
+		inj := newInjector()
+		inj.Map("outer")
+		inj.Map(1)
+
+		var result string
+		_, err := inj.Invoke(injectorInvoker(func(s string, n int) {
+			result += fmt.Sprint(s, n)
+			_, err := inj.Invoke(func(n int) { result += fmt.Sprint(n) })
+			So(err, ShouldBeNil)
+			_, err = inj.Invoke(injectorInvoker(func(s string, n int) { result += s }))
+			So(err, ShouldBeNil)
+			result += s
+		}))
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, "outer11outerouter")
+
+		_, err = inj.Invoke(func(float64) {})
+		So(err, ShouldNotBeNil)
+	})
+}
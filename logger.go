@@ -41,16 +41,26 @@ func (invoke LoggerInvoker) Invoke(params []interface{}) ([]reflect.Value, error
 	return nil, nil
 }
 
+// requestIDSuffix returns the request ID to append to log messages if there is one.
+func requestIDSuffix(ctx *Context) string {
+	if len(ctx.RequestID()) == 0 {
+		return ""
+	}
+	return " [" + ctx.RequestID() + "]"
+}
+
 // Logger returns a middleware handler that logs the request as it goes in and the response as it goes out.
+// The request ID is included when the RequestID middleware is used, no matter it is used before or after,
+// see Context.RequestID.
 func Logger() Handler {
 	return func(ctx *Context, log *log.Logger) {
 		start := time.Now()
 
-		log.Printf("%s: Started %s %s for %s", time.Now().Format(LogTimeFormat), ctx.Req.Method, ctx.Req.RequestURI, ctx.RemoteAddr())
+		log.Printf("%s: Started %s %s for %s%s", time.Now().Format(LogTimeFormat), ctx.Req.Method, ctx.Req.RequestURI, ctx.RemoteAddr(), requestIDSuffix(ctx))
 
 		ctx.Next()
 
-		content := fmt.Sprintf("%s: Completed %s %s %v %s in %v", time.Now().Format(LogTimeFormat), ctx.Req.Method, ctx.Req.RequestURI, ctx.Resp.Status(), http.StatusText(ctx.Resp.Status()), time.Since(start))
+		content := fmt.Sprintf("%s: Completed %s %s %v %s in %v%s", time.Now().Format(LogTimeFormat), ctx.Req.Method, ctx.Req.RequestURI, ctx.Resp.Status(), http.StatusText(ctx.Resp.Status()), time.Since(start), requestIDSuffix(ctx))
 		if ColorLog {
 			switch ctx.Resp.Status() {
 			case 200, 201, 202:
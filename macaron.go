@@ -16,6 +16,7 @@
 package macaron // import "gopkg.in/macaron.v1"
 
 import (
+	"errors"
 	"io"
 	"log"
 	"net/http"
@@ -61,7 +62,7 @@ func (invoke internalServerErrorInvoker) Invoke(params []interface{}) ([]reflect
 // When the handler is also potential to be any built-in inject.FastInvoker,
 // it wraps the handler automatically to have some performance gain.
 func validateAndWrapHandler(h Handler) Handler {
-	if reflect.TypeOf(h).Kind() != reflect.Func {
+	if reflect.TypeOf(handlerFunc(h)).Kind() != reflect.Func {
 		panic("Macaron handler must be a callable function")
 	}
 
@@ -113,6 +114,17 @@ type Macaron struct {
 	*Router
 
 	logger *log.Logger
+	// requestID is the handler of RequestID middleware used by the instance.
+	requestID requestIDInvoker
+	// provided is the types of services declared to be mapped by middleware.
+	provided []reflect.Type
+
+	// chains is all handler chains of routes and special cases, which are rebuilt
+	// when global middleware is changed.
+	chains      []*handlerChain
+	contextPool sync.Pool
+
+	lifecycle *lifecycle
 }
 
 // NewWithLogger creates a bare bones Macaron instance.
@@ -124,12 +136,22 @@ func NewWithLogger(out io.Writer) *Macaron {
 		action:   func() {},
 		Router:   NewRouter(),
 		logger:   log.New(out, "[Macaron] ", 0),
+
+		lifecycle: newLifecycle(),
 	}
 	m.m = m
 	m.Map(m.logger)
 	m.Map(defaultReturnHandler())
 	m.NotFound(http.NotFound)
+	m.MethodNotAllowed(func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	})
 	m.InternalServerError(func(rw http.ResponseWriter, err error) {
+		var e *HTTPError
+		if errors.As(err, &e) {
+			http.Error(rw, e.Message, ErrorStatus(err))
+			return
+		}
 		http.Error(rw, err.Error(), 500)
 	})
 	return m
@@ -156,9 +178,11 @@ func Classic() *Macaron {
 // and panics if any of the handlers is not a callable function
 func (m *Macaron) Handlers(handlers ...Handler) {
 	m.handlers = make([]Handler, 0)
+	m.requestID = nil
 	for _, handler := range handlers {
 		m.Use(handler)
 	}
+	m.rebuildHandlerChains()
 }
 
 // Action sets the handler that will be called after all the middleware has been invoked.
@@ -181,21 +205,66 @@ func (m *Macaron) Before(handler BeforeHandler) {
 // Middleware Handlers are invoked in the order that they are added.
 func (m *Macaron) Use(handler Handler) {
 	handler = validateAndWrapHandler(handler)
+	if h, ok := handler.(requestIDInvoker); ok && m.requestID == nil {
+		m.requestID = h
+	}
 	m.handlers = append(m.handlers, handler)
+	m.rebuildHandlerChains()
+}
+
+// handlerChain is the chain of handlers of global middleware followed by handlers of a route
+// or a special case like NotFound. It is built at registration to save work per request.
+type handlerChain struct {
+	own      []Handler
+	handlers []Handler
+}
+
+// newHandlerChain builds and tracks the chain of given handlers. The chain is not tracked
+// for routers not bound to a Macaron instance.
+func (m *Macaron) newHandlerChain(handlers []Handler) *handlerChain {
+	chain := &handlerChain{own: handlers}
+	if m == nil {
+		chain.build(nil)
+		return chain
+	}
+	chain.build(m.handlers)
+	m.chains = append(m.chains, chain)
+	return chain
+}
+
+func (chain *handlerChain) build(middleware []Handler) {
+	chain.handlers = make([]Handler, 0, len(middleware)+len(chain.own))
+	chain.handlers = append(chain.handlers, middleware...)
+	chain.handlers = append(chain.handlers, chain.own...)
+}
+
+// rebuildHandlerChains rebuilds all chains for changed global middleware.
+func (m *Macaron) rebuildHandlerChains() {
+	for _, chain := range m.chains {
+		chain.build(m.handlers)
+	}
 }
 
+// createContext takes a Context from the pool and initializes it for given request.
+// It should be released by releaseContext once the request is served.
 func (m *Macaron) createContext(rw http.ResponseWriter, req *http.Request) *Context {
-	c := &Context{
-		Injector: inject.New(),
-		handlers: m.handlers,
-		action:   m.action,
-		index:    0,
-		Router:   m.Router,
-		Req:      Request{req},
-		Resp:     NewResponseWriter(req.Method, rw),
-		Render:   &DummyRender{rw},
-		Data:     make(map[string]interface{}),
+	c, _ := m.contextPool.Get().(*Context)
+	if c == nil {
+		c = &Context{
+			inj:  newInjector(),
+			Data: make(map[string]interface{}),
+		}
+		c.Injector = c.inj
 	}
+
+	c.handlers = m.handlers
+	c.action = m.action
+	c.Router = m.Router
+	c.Req = Request{req}
+	c.resp = responseWriter{method: req.Method, ResponseWriter: rw, beforeFuncs: c.resp.beforeFuncs[:0]}
+	c.Resp = &c.resp
+	c.dummyRender = DummyRender{rw}
+	c.Render = &c.dummyRender
 	c.SetParent(m)
 	c.Map(c)
 	c.MapTo(c.Resp, (*http.ResponseWriter)(nil))
@@ -203,6 +272,32 @@ func (m *Macaron) createContext(rw http.ResponseWriter, req *http.Request) *Cont
 	return c
 }
 
+// releaseContext resets the Context and puts it back to the pool.
+func (m *Macaron) releaseContext(c *Context) {
+	c.inj.reset()
+	c.handlers = nil
+	c.action = nil
+	c.index = 0
+	c.Router = nil
+	c.Req = Request{}
+	clear(c.resp.beforeFuncs)
+	c.resp = responseWriter{beforeFuncs: c.resp.beforeFuncs}
+	c.Resp = nil
+	c.params = nil
+	c.paramTypes = nil
+	c.pattern = ""
+	c.requestID = ""
+	c.dummyRender = DummyRender{}
+	c.Render = nil
+	c.Locale = nil
+	if c.Data == nil {
+		c.Data = make(map[string]interface{})
+	} else {
+		clear(c.Data)
+	}
+	m.contextPool.Put(c)
+}
+
 // ServeHTTP is the HTTP Entry point for a Macaron instance.
 // Useful if you want to control your own HTTP server.
 // Be aware that none of middleware will run without registering any router.
@@ -231,28 +326,16 @@ func GetDefaultListenInfo() (string, int) {
 }
 
 // Run the http server. Listening on os.GetEnv("PORT") or 4000 by default.
+// It exits the process when the server fails, use ListenAndServe to handle the error instead.
 func (m *Macaron) Run(args ...interface{}) {
-	host, port := GetDefaultListenInfo()
-	if len(args) == 1 {
-		switch arg := args[0].(type) {
-		case string:
-			host = arg
-		case int:
-			port = arg
-		}
-	} else if len(args) >= 2 {
-		if arg, ok := args[0].(string); ok {
-			host = arg
-		}
-		if arg, ok := args[1].(int); ok {
-			port = arg
-		}
+	if err := m.ListenAndServe(args...); err != nil {
+		m.getLogger().Fatalln(err)
 	}
+}
 
-	addr := host + ":" + com.ToStr(port)
-	logger := m.GetVal(reflect.TypeOf(m.logger)).Interface().(*log.Logger)
-	logger.Printf("listening on %s (%s)\n", addr, safeEnv())
-	logger.Fatalln(http.ListenAndServe(addr, m))
+// getLogger returns the logger currently mapped on the global level.
+func (m *Macaron) getLogger() *log.Logger {
+	return m.GetVal(reflect.TypeOf(m.logger)).Interface().(*log.Logger)
 }
 
 // SetURLPrefix sets URL prefix of router layer, so that it support suburl.
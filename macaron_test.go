@@ -19,6 +19,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -191,6 +192,45 @@ func Test_Macaron_Basic_NoRace(t *testing.T) {
 	})
 }
 
+func Test_Macaron_ContextPool(t *testing.T) {
+	Convey("Reset pooled contexts between requests", t, func() {
+		m := New()
+		m.Get("/static", func(ctx *Context) string {
+			So(ctx.Data, ShouldBeEmpty)
+			So(ctx.Params(":id"), ShouldBeBlank)
+			So(ctx.GetVal(reflect.TypeOf("")).IsValid(), ShouldBeFalse)
+
+			ctx.Data["Title"] = "static"
+			ctx.SetParams(":id", "1")
+			ctx.Map("mapped")
+			return ctx.Params(":id")
+		})
+
+		for i := 0; i < 3; i++ {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/static", nil)
+			So(err, ShouldBeNil)
+			m.ServeHTTP(resp, req)
+			So(resp.Body.String(), ShouldEqual, "1")
+		}
+	})
+
+	Convey("Apply middleware added after routes", t, func() {
+		m := New()
+		m.Get("/", func() string { return "route" })
+		m.NotFound(func() string { return "not found" })
+		m.Use(func(ctx *Context) { ctx.Resp.Header().Set("X-Middleware", "true") })
+
+		for _, url := range []string{"/", "/404"} {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", url, nil)
+			So(err, ShouldBeNil)
+			m.ServeHTTP(resp, req)
+			So(resp.Header().Get("X-Middleware"), ShouldEqual, "true")
+		}
+	})
+}
+
 func Test_SetENV(t *testing.T) {
 	Convey("Get and save environment variable", t, func() {
 		tests := []struct {
@@ -216,3 +256,55 @@ func Test_Config(t *testing.T) {
 		So(cfg, ShouldNotBeNil)
 	})
 }
+
+// discardResponseWriter is an http.ResponseWriter that discards everything written,
+// so that benchmarks only count allocations of Macaron.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (rw *discardResponseWriter) Header() http.Header         { return rw.header }
+func (rw *discardResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (rw *discardResponseWriter) WriteHeader(int)             {}
+
+func benchmarkServeHTTP(b *testing.B, m *Macaron, url string) {
+	rw := &discardResponseWriter{make(http.Header)}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.ServeHTTP(rw, req)
+	}
+}
+
+func Benchmark_Macaron_StaticRoute(b *testing.B) {
+	m := New()
+	m.Get("/static", func(ctx *Context) {})
+	benchmarkServeHTTP(b, m, "/static")
+}
+
+func Benchmark_Macaron_ParamRoute(b *testing.B) {
+	m := New()
+	m.Get("/user/:id", func(ctx *Context) {})
+	benchmarkServeHTTP(b, m, "/user/1")
+}
+
+func Benchmark_Macaron_Middleware(b *testing.B) {
+	m := New()
+	for i := 0; i < 5; i++ {
+		m.Use(func(ctx *Context) { ctx.Next() })
+	}
+	m.Get("/static", func(ctx *Context) {})
+	benchmarkServeHTTP(b, m, "/static")
+}
+
+func Benchmark_Macaron_Injection(b *testing.B) {
+	m := New()
+	m.Use(func(w http.ResponseWriter, req *http.Request) {})
+	m.Get("/static", func(ctx *Context, req *http.Request) {})
+	benchmarkServeHTTP(b, m, "/static")
+}
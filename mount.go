@@ -0,0 +1,93 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// stripSegments removes given number of leading segments from the path, the result is "/"
+// when nothing is left.
+func stripSegments(path string, n int) string {
+	i := 0
+	for ; n > 0; n-- {
+		j := strings.IndexByte(path[i+1:], '/')
+		if j == -1 {
+			return "/"
+		}
+		i += j + 1
+	}
+	return path[i:]
+}
+
+// mountHandler returns the handler forwarding requests to h with given number of leading
+// segments stripped from the path.
+func mountHandler(h http.Handler, segments int) func(*Context) {
+	return func(ctx *Context) {
+		req := ctx.Req.Request
+		u := *req.URL
+		u.Path = stripSegments(req.URL.Path, segments)
+		u.RawPath = stripSegments(req.URL.EscapedPath(), segments)
+		if path, err := url.PathUnescape(u.RawPath); err == nil {
+			u.Path = path
+		}
+		if u.RawPath == u.Path {
+			u.RawPath = ""
+		}
+
+		forward := new(http.Request)
+		*forward = *req
+		forward.URL = &u
+		h.ServeHTTP(ctx.Resp, forward)
+	}
+}
+
+// mountPrefix returns the prefix of paths of the router built from all Macaron instances
+// it is mounted to.
+func (r *Router) mountPrefix() string {
+	prefix := ""
+	for p := r; p != nil; p = p.mountParent {
+		prefix = p.mountPattern + prefix
+	}
+	return prefix
+}
+
+// Mount registers h to serve requests of all methods whose path is the prefix or under it,
+// with the prefix stripped from the path, e.g. "/admin/users" is forwarded as "/users" for
+// the prefix "/admin". The prefix can contain wildcards but no optional segments, and groups
+// it is registered within are also stripped. Global middleware runs before h.
+//
+// A mounted Macaron instance keeps its own middleware and NotFound handler, and URLFor of it
+// builds paths with the prefix, where wildcards of the prefix are filled by the pairs as well.
+func (r *Router) Mount(prefix string, h http.Handler) {
+	prefix = strings.TrimRight(prefix, "/")
+	pattern := ""
+	for _, g := range r.groups {
+		pattern += g.pattern
+	}
+	pattern += prefix
+	if child, ok := h.(*Macaron); ok {
+		child.mountParent = r
+		child.mountPattern = getRawPattern(pattern)
+	}
+
+	handler := mountHandler(h, strings.Count(pattern, "/"))
+	if len(prefix) > 0 {
+		r.Any(prefix, handler)
+	}
+	r.Any(prefix+"/*", handler)
+}
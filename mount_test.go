@@ -0,0 +1,107 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_stripSegments(t *testing.T) {
+	Convey("Strip leading segments of paths", t, func() {
+		cases := []struct {
+			path     string
+			n        int
+			expected string
+		}{
+			{"/api/users", 1, "/users"},
+			{"/api", 1, "/"},
+			{"/api/", 1, "/"},
+			{"/org/api/a%2Fb/c", 2, "/a%2Fb/c"},
+			{"/users", 0, "/users"},
+		}
+		for _, c := range cases {
+			So(stripSegments(c.path, c.n), ShouldEqual, c.expected)
+		}
+	})
+}
+
+func Test_Router_Mount(t *testing.T) {
+	serve := func(m *Macaron, method, url string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest(method, url, nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		return resp
+	}
+
+	Convey("Mount http.Handler", t, func() {
+		m := New()
+		m.Use(func(ctx *Context) { ctx.Resp.Header().Set("X-Parent", "true") })
+		m.Group("/static", func() {
+			m.Mount("/files/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				_, _ = w.Write([]byte(req.Method + " " + req.URL.Path + " " + req.URL.EscapedPath()))
+			}))
+		})
+
+		resp := serve(m, "GET", "/static/files/a/b.txt")
+		So(resp.Body.String(), ShouldEqual, "GET /a/b.txt /a/b.txt")
+		So(resp.Header().Get("X-Parent"), ShouldEqual, "true")
+		So(serve(m, "POST", "/static/files").Body.String(), ShouldEqual, "POST / /")
+		So(serve(m, "GET", "/static/files/a%2Fb").Body.String(), ShouldEqual, "GET /a/b /a%2Fb")
+		So(serve(m, "GET", "/static/other").Code, ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("Mount Macaron instance", t, func() {
+		admin := New()
+		admin.Use(func(ctx *Context) { ctx.Resp.Header().Set("X-Admin", "true") })
+		admin.Get("/", func() string { return "dashboard" })
+		admin.Get("/users/:id", func(ctx *Context) string {
+			return ctx.URLFor("user", "id", ctx.Params("id"), "org", "acme")
+		}).Name("user")
+		admin.NotFound(func(ctx *Context) {
+			ctx.Resp.WriteHeader(http.StatusNotFound)
+			_, _ = ctx.Resp.Write([]byte("admin not found"))
+		})
+
+		m := New()
+		m.Get("/", func() string { return "home" })
+		m.Mount("/:org/admin", admin)
+
+		So(serve(m, "GET", "/").Body.String(), ShouldEqual, "home")
+		resp := serve(m, "GET", "/acme/admin")
+		So(resp.Body.String(), ShouldEqual, "dashboard")
+		So(resp.Header().Get("X-Admin"), ShouldEqual, "true")
+		So(serve(m, "GET", "/acme/admin/users/1").Body.String(), ShouldEqual, "/acme/admin/users/1")
+		So(admin.URLFor("user", "org", "acme", "id", "2"), ShouldEqual, "/acme/admin/users/2")
+
+		resp = serve(m, "GET", "/acme/admin/unknown")
+		So(resp.Code, ShouldEqual, http.StatusNotFound)
+		So(resp.Body.String(), ShouldEqual, "admin not found")
+		So(serve(m, "GET", "/unknown").Body.String(), ShouldEqual, "404 page not found\n")
+
+		Convey("Mount nested Macaron instances", func() {
+			api := New()
+			api.Get("/status", func() string { return "ok" }).Name("status")
+			admin.Mount("/api", api)
+
+			So(serve(m, "GET", "/acme/admin/api/status").Body.String(), ShouldEqual, "ok")
+			So(api.URLFor("status", "org", "acme"), ShouldEqual, "/acme/admin/api/status")
+		})
+	})
+}
@@ -0,0 +1,219 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NegotiateOptions is a struct for specifying how to render each media type in content negotiation.
+type NegotiateOptions struct {
+	// Template is the name of template to render for "text/html".
+	// HTML is not offered if it is empty.
+	Template string
+	// TemplateSet is the name of template set to render the Template. Default is DEFAULT_TPL_SET_NAME.
+	TemplateSet string
+	// HTMLOptions overrides the rendering options of the Template.
+	HTMLOptions *HTMLOptions
+	// Offers is the media types to choose from in the order of server preference.
+	// Default is JSON, XML, HTML, plain text and then registered encoders.
+	Offers []string
+}
+
+// acceptRange represents a media range with its quality in an Accept header.
+type acceptRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// match returns how specific the media range is for given media type, e.g. "text/html" is more
+// specific than "text/*", or -1 if the media range does not cover it. Media types with structured
+// syntax suffixes cover their base types less specifically than the base types themselves, e.g.
+// "application/vnd.example.v2+json" covers "application/json".
+func (ar acceptRange) match(mediaType string) int {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	switch {
+	case ar.typ == "*":
+		return 0
+	case ar.typ != typ:
+		return -1
+	case ar.subtype == "*":
+		return 1
+	case ar.subtype == subtype:
+		return 3
+	case strings.HasSuffix(ar.subtype, "+"+subtype):
+		return 2
+	}
+	return -1
+}
+
+// parseAccept parses the value of an Accept header into media ranges.
+// Media ranges with invalid format are ignored.
+func parseAccept(header string) []acceptRange {
+	ranges := make([]acceptRange, 0, 4)
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+		typ, subtype, ok := strings.Cut(mediaRange, "/")
+		if !ok || len(typ) == 0 || len(subtype) == 0 {
+			continue
+		}
+
+		ar := acceptRange{typ: typ, subtype: subtype, q: 1}
+		for _, param := range params[1:] {
+			key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.ToLower(strings.TrimSpace(key)) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+			if err == nil && q >= 0 && q <= 1 {
+				ar.q = q
+			}
+		}
+		ranges = append(ranges, ar)
+	}
+	return ranges
+}
+
+// quality returns the quality of given media type by the most specific
+// media range that covers it, 0 is returned if none covers it.
+func quality(ranges []acceptRange, mediaType string) float64 {
+	q, specificity := 0.0, -1
+	for _, ar := range ranges {
+		if s := ar.match(mediaType); s > specificity {
+			q, specificity = ar.q, s
+		}
+	}
+	return q
+}
+
+// NegotiateContentType returns the best media type of offers acceptable by given Accept header,
+// ties are broken by the order of offers. It returns an empty string if none is acceptable.
+// All offers are acceptable when the header is empty.
+func NegotiateContentType(accept string, offers []string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if len(strings.TrimSpace(accept)) == 0 {
+		return offers[0]
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q := quality(ranges, strings.ToLower(offer)); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// acceptsExplicitly returns true if the Accept header lists any of offers by its type
+// rather than by wildcards, including the ones listed as not acceptable.
+func acceptsExplicitly(header string, offers []string) bool {
+	for _, ar := range parseAccept(header) {
+		for _, offer := range offers {
+			if ar.match(strings.ToLower(offer)) > 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addVary adds given header name to the Vary header unless it is already listed.
+func addVary(h http.Header, name string) {
+	for _, v := range h.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			field = strings.TrimSpace(field)
+			if field == "*" || strings.EqualFold(field, name) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", name)
+}
+
+// negotiateOffers returns media types that can be rendered with given options.
+func (ctx *Context) negotiateOffers(opt NegotiateOptions) []string {
+	if len(opt.Offers) > 0 {
+		return opt.Offers
+	}
+
+	offers := []string{_CONTENT_JSON, "application/xml", _CONTENT_XML}
+	if len(opt.Template) > 0 {
+		offers = append(offers, _CONTENT_HTML)
+	}
+	offers = append(offers, _CONTENT_PLAIN)
+	for _, typ := range ctx.MediaTypes() {
+		switch typ {
+		case _CONTENT_JSON, "application/xml", _CONTENT_XML, _CONTENT_HTML, _CONTENT_PLAIN:
+			continue
+		}
+		offers = append(offers, typ)
+	}
+	return offers
+}
+
+// Negotiate renders data with given status in the media type that best matches the
+// Accept header of the request, and sets "Vary: Accept" header. Built-in media types
+// are JSON, XML, HTML when a template is given and plain text; encoders registered
+// in RenderOptions.Encoders are offered as well. Media types with structured syntax suffixes,
+// e.g. "application/vnd.example.v2+json", are rendered as their base types. It responds with
+// 406 Not Acceptable when none of media types is acceptable.
+func (ctx *Context) Negotiate(status int, data interface{}, opts ...NegotiateOptions) {
+	var opt NegotiateOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	addVary(ctx.Resp.Header(), "Accept")
+	mediaType := NegotiateContentType(ctx.Req.Header.Get("Accept"), ctx.negotiateOffers(opt))
+	switch strings.ToLower(mediaType) {
+	case "":
+		http.Error(ctx.Resp, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+	case _CONTENT_JSON:
+		ctx.Render.JSON(status, data)
+	case "application/xml", _CONTENT_XML:
+		ctx.Render.XML(status, data)
+	case _CONTENT_HTML:
+		setName := opt.TemplateSet
+		if len(setName) == 0 {
+			setName = DEFAULT_TPL_SET_NAME
+		}
+		if opt.HTMLOptions != nil {
+			ctx.Render.HTMLSet(status, setName, opt.Template, data, *opt.HTMLOptions)
+		} else {
+			ctx.Render.HTMLSet(status, setName, opt.Template, data)
+		}
+	case _CONTENT_PLAIN:
+		var body []byte
+		switch v := data.(type) {
+		case []byte:
+			body = v
+		case string:
+			body = []byte(v)
+		default:
+			body = []byte(fmt.Sprint(v))
+		}
+		ctx.Render.PlainText(status, body)
+	default:
+		ctx.Encode(status, mediaType, data)
+	}
+}
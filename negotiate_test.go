@@ -0,0 +1,159 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_NegotiateContentType(t *testing.T) {
+	Convey("Negotiate content type by Accept header", t, func() {
+		offers := []string{"application/json", "text/xml", "text/html"}
+		tests := []struct {
+			accept string
+			expect string
+		}{
+			{"", "application/json"},
+			{"*/*", "application/json"},
+			{"text/html", "text/html"},
+			{"text/*", "text/xml"},
+			{"text/*;q=0.5, text/html", "text/html"},
+			{"application/json;q=0.2, text/xml;q=0.8", "text/xml"},
+			{"application/json;q=0, */*", "text/xml"},
+			{"TEXT/HTML; Q=0.9, image/png", "text/html"},
+			{"image/png", ""},
+			{"invalid, text/xml", "text/xml"},
+			{"application/vnd.example.v2+json", "application/json"},
+			{"text/html;q=0.5, application/vnd.example.v2+json", "application/json"},
+			{"application/vnd.example+xml", ""},
+			{"application/json;q=0.1, application/vnd.example+json, text/html;q=0.5", "text/html"},
+			{"application/*;q=0.2, application/vnd.example+json;q=0.9, text/html;q=0.5", "application/json"},
+		}
+		for _, test := range tests {
+			So(NegotiateContentType(test.accept, offers), ShouldEqual, test.expect)
+		}
+		So(NegotiateContentType("*/*", nil), ShouldBeEmpty)
+	})
+}
+
+func Test_Context_Negotiate(t *testing.T) {
+	Convey("Negotiate response format", t, func() {
+		m := New()
+		m.Use(Renderer(RenderOptions{
+			Directory: "fixtures/basic",
+			Encoders: map[string]Encoder{
+				"text/csv": EncoderFunc(func(w io.Writer, v interface{}, _ *RenderOptions) error {
+					g := v.(Greeting)
+					_, err := fmt.Fprintf(w, "%s,%s", g.One, g.Two)
+					return err
+				}),
+			},
+		}))
+		m.Get("/", func(ctx *Context) {
+			ctx.Negotiate(http.StatusCreated, Greeting{"hello", "world"}, NegotiateOptions{Template: "hello"})
+		})
+		m.Get("/offers", func(ctx *Context) {
+			ctx.Negotiate(http.StatusOK, "plain", NegotiateOptions{Offers: []string{"text/plain"}})
+		})
+
+		negotiate := func(target, accept string) *httptest.ResponseRecorder {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", target, nil)
+			So(err, ShouldBeNil)
+			if len(accept) > 0 {
+				req.Header.Set("Accept", accept)
+			}
+			m.ServeHTTP(resp, req)
+			So(resp.Header().Get("Vary"), ShouldEqual, "Accept")
+			return resp
+		}
+
+		resp := negotiate("/", "")
+		So(resp.Code, ShouldEqual, http.StatusCreated)
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldEqual, _CONTENT_JSON+"; charset=UTF-8")
+		So(resp.Body.String(), ShouldEqual, `{"one":"hello","two":"world"}`)
+
+		resp = negotiate("/", "application/xml")
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldEqual, _CONTENT_XML+"; charset=UTF-8")
+
+		resp = negotiate("/", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldEqual, _CONTENT_HTML+"; charset=UTF-8")
+		So(resp.Body.String(), ShouldEqual, "<h1>Hello {hello world}</h1>")
+
+		resp = negotiate("/", "text/plain")
+		So(resp.Body.String(), ShouldEqual, "{hello world}")
+
+		resp = negotiate("/", "text/csv")
+		So(resp.Code, ShouldEqual, http.StatusCreated)
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldEqual, "text/csv; charset=UTF-8")
+		So(resp.Body.String(), ShouldEqual, "hello,world")
+
+		resp = negotiate("/", "image/png")
+		So(resp.Code, ShouldEqual, http.StatusNotAcceptable)
+
+		resp = negotiate("/offers", "application/json")
+		So(resp.Code, ShouldEqual, http.StatusNotAcceptable)
+		resp = negotiate("/offers", "")
+		So(resp.Body.String(), ShouldEqual, "plain")
+	})
+
+	Convey("Encode with unregistered media type", t, func() {
+		m := New()
+		m.Use(Renderer())
+		m.Get("/", func(ctx *Context) {
+			ctx.Encode(http.StatusOK, "text/x-unknown", nil)
+		})
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusInternalServerError)
+	})
+
+	Convey("Negotiate with Render not implementing MediaRender", t, func() {
+		m := New()
+		m.Use(Renderer())
+		m.Use(func(ctx *Context) {
+			ctx.Render = struct{ Render }{ctx.Render}
+		})
+		m.Get("/", func(ctx *Context) {
+			ctx.Negotiate(http.StatusOK, map[string]string{"hello": "world"})
+		})
+		m.Get("/encode", func(ctx *Context) {
+			ctx.Encode(http.StatusOK, "text/csv", nil)
+		})
+
+		for accept, code := range map[string]int{"application/json": http.StatusOK, "text/csv": http.StatusNotAcceptable} {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/", nil)
+			So(err, ShouldBeNil)
+			req.Header.Set("Accept", accept)
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, code)
+		}
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/encode", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusInternalServerError)
+	})
+}
@@ -17,6 +17,7 @@ package macaron
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -131,30 +132,139 @@ func function(pc uintptr) []byte {
 	return name
 }
 
+// StackFrame is a frame of the call stack.
+type StackFrame struct {
+	Func string
+	File string
+	Line int
+}
+
+// Stack is the parsed call stack where a panic happened, the innermost frame comes first.
+type Stack []StackFrame
+
+// String formats the stack like runtime/debug.Stack does.
+func (s Stack) String() string {
+	buf := new(bytes.Buffer)
+	for _, f := range s {
+		fmt.Fprintf(buf, "%s\n\t%s:%d\n", f.Func, f.File, f.Line)
+	}
+	return buf.String()
+}
+
+// callers returns the parsed call stack, skipping skip frames.
+func callers(skip int) Stack {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	stack := make(Stack, 0, n)
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, StackFrame{frame.Function, frame.File, frame.Line})
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// PanicValue is the value recovered from a panic, which is mapped for the panic handler of Recovery.
+type PanicValue interface{}
+
+// RecoveryOptions represents a struct for specifying configuration options for the Recovery middleware.
+type RecoveryOptions struct {
+	// PanicHandler is invoked to respond once a panic is recovered, with *Context, PanicValue
+	// and Stack mapped to be injected. Default responds with 500 in the media type negotiated
+	// by the Accept header, including details of the panic in development mode.
+	PanicHandler Handler
+	// Reporter is called with every recovered panic before responding, e.g. to send it to
+	// an error tracking service.
+	Reporter func(ctx *Context, value interface{}, stack Stack)
+}
+
+func prepareRecoveryOptions(options []RecoveryOptions) RecoveryOptions {
+	var opt RecoveryOptions
+	if len(options) > 0 {
+		opt = options[0]
+	}
+	if opt.PanicHandler != nil {
+		opt.PanicHandler = validateAndWrapHandler(opt.PanicHandler)
+	}
+	return opt
+}
+
+// respondPanic responds with the panic in the media type negotiated by the Accept header.
+// Details of the panic are only included in development mode.
+func respondPanic(res http.ResponseWriter, req *http.Request, err interface{}, stack []byte) {
+	status := http.StatusInternalServerError
+	mediaType := NegotiateContentType(req.Header.Get("Accept"),
+		[]string{_CONTENT_HTML, _CONTENT_PROBLEM_JSON, _CONTENT_JSON, _CONTENT_PLAIN})
+
+	var body []byte
+	switch mediaType {
+	case _CONTENT_PROBLEM_JSON, _CONTENT_JSON:
+		problem := &Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(status),
+			Status: status,
+		}
+		if Env == DEV {
+			problem.Detail = fmt.Sprint(err)
+		}
+		body, _ = json.Marshal(problem)
+		res.Header().Set(_CONTENT_TYPE, mediaType)
+	case _CONTENT_PLAIN:
+		if Env == DEV {
+			body = []byte(fmt.Sprintf("PANIC: %s\n%s", err, stack))
+			res.Header().Set(_CONTENT_TYPE, _CONTENT_PLAIN+"; charset=utf-8")
+		}
+	default:
+		// respond with panic message while in development mode
+		if Env == DEV {
+			res.Header().Set(_CONTENT_TYPE, _CONTENT_HTML)
+			body = []byte(fmt.Sprintf(panicHtml, err, err, stack))
+		}
+	}
+
+	res.WriteHeader(status)
+	if nil != body {
+		_, _ = res.Write(body)
+	}
+}
+
 // Recovery returns a middleware that recovers from any panics and writes a 500 if there was one.
-// While Martini is in development mode, Recovery will also output the panic as HTML.
-func Recovery() Handler {
+// While Martini is in development mode, Recovery will also output the panic as HTML, or as JSON
+// and plain text if the client prefers. Nothing is written if the response has been written partially.
+func Recovery(options ...RecoveryOptions) Handler {
+	opt := prepareRecoveryOptions(options)
+
 	return func(c *Context, log *log.Logger) {
 		defer func() {
 			if err := recover(); err != nil {
 				stack := stack(3)
-				log.Printf("PANIC: %s\n%s", err, stack)
+				log.Printf("PANIC%s: %s\n%s", requestIDSuffix(c), err, stack)
+
+				if opt.Reporter != nil {
+					opt.Reporter(c, err, callers(3))
+				}
 
 				// Lookup the current responsewriter
 				val := c.GetVal(inject.InterfaceOf((*http.ResponseWriter)(nil)))
 				res := val.Interface().(http.ResponseWriter)
 
-				// respond with panic message while in development mode
-				var body []byte
-				if Env == DEV {
-					res.Header().Set("Content-Type", "text/html")
-					body = []byte(fmt.Sprintf(panicHtml, err, err, stack))
+				// The status and part of body have been sent, it's too late to respond.
+				if rw, ok := res.(ResponseWriter); ok && rw.Written() {
+					return
 				}
 
-				res.WriteHeader(http.StatusInternalServerError)
-				if nil != body {
-					_, _ = res.Write(body)
+				if opt.PanicHandler != nil {
+					c.MapTo(err, (*PanicValue)(nil))
+					c.Map(callers(3))
+					if _, err := c.Invoke(opt.PanicHandler); err != nil {
+						panic(err)
+					}
+					return
 				}
+				respondPanic(res, c.Req.Request, err, stack)
 			}
 		}()
 
@@ -72,3 +72,77 @@ func Test_Recovery(t *testing.T) {
 		So(resp2.Body.Len(), ShouldBeGreaterThan, 0)
 	})
 }
+
+func Test_Recovery_Options(t *testing.T) {
+	newMacaron := func(opts ...RecoveryOptions) *Macaron {
+		m := NewWithLogger(&bytes.Buffer{})
+		m.Use(Recovery(opts...))
+		m.Get("/", func() { panic("here is a panic!") })
+		m.Get("/partial", func(ctx *Context) {
+			ctx.Resp.Write([]byte("partial"))
+			panic("here is a panic!")
+		})
+		return m
+	}
+	request := func(m *Macaron, url, accept string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", url, nil)
+		So(err, ShouldBeNil)
+		req.Header.Set("Accept", accept)
+		m.ServeHTTP(resp, req)
+		return resp
+	}
+
+	Convey("Respond in negotiated media type", t, func() {
+		setENV(DEV)
+		m := newMacaron()
+
+		resp := request(m, "/", "application/json")
+		So(resp.Code, ShouldEqual, http.StatusInternalServerError)
+		So(resp.Header().Get("Content-Type"), ShouldEqual, "application/json")
+		So(resp.Body.String(), ShouldEqual, `{"detail":"here is a panic!","status":500,"title":"Internal Server Error","type":"about:blank"}`)
+
+		resp = request(m, "/", "text/plain")
+		So(resp.Header().Get("Content-Type"), ShouldEqual, "text/plain; charset=utf-8")
+		So(resp.Body.String(), ShouldStartWith, "PANIC: here is a panic!\n")
+
+		setENV(PROD)
+		defer setENV(DEV)
+		resp = request(m, "/", "application/problem+json")
+		So(resp.Header().Get("Content-Type"), ShouldEqual, "application/problem+json")
+		So(resp.Body.String(), ShouldEqual, `{"status":500,"title":"Internal Server Error","type":"about:blank"}`)
+
+		resp = request(m, "/", "text/html")
+		So(resp.Code, ShouldEqual, http.StatusInternalServerError)
+		So(resp.Body.Len(), ShouldEqual, 0)
+	})
+
+	Convey("Respond with custom panic handler and reporter", t, func() {
+		var reported interface{}
+		var reportedStack Stack
+		m := newMacaron(RecoveryOptions{
+			PanicHandler: func(ctx *Context, v PanicValue, stack Stack) {
+				So(stack[0].Func, ShouldEndWith, "Test_Recovery_Options.func1.1")
+				ctx.Resp.WriteHeader(http.StatusServiceUnavailable)
+				ctx.Resp.Write([]byte(v.(string)))
+			},
+			Reporter: func(ctx *Context, value interface{}, stack Stack) {
+				reported, reportedStack = value, stack
+			},
+		})
+
+		resp := request(m, "/", "")
+		So(resp.Code, ShouldEqual, http.StatusServiceUnavailable)
+		So(resp.Body.String(), ShouldEqual, "here is a panic!")
+		So(reported, ShouldEqual, "here is a panic!")
+		So(reportedStack.String(), ShouldContainSubstring, "recovery_test.go")
+
+		resp = request(m, "/partial", "")
+		So(resp.Code, ShouldEqual, http.StatusOK)
+		So(resp.Body.String(), ShouldEqual, "partial")
+	})
+
+	Convey("Use invalid panic handler", t, func() {
+		So(func() { Recovery(RecoveryOptions{PanicHandler: "handler"}) }, ShouldPanic)
+	})
+}
@@ -19,29 +19,38 @@ import (
 	"bytes"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"html/template"
 	"io"
+	"io/fs"
+	"maps"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+	"weak"
 
 	"github.com/unknwon/com"
 )
 
 const (
-	_CONTENT_TYPE    = "Content-Type"
-	_CONTENT_BINARY  = "application/octet-stream"
-	_CONTENT_JSON    = "application/json"
-	_CONTENT_HTML    = "text/html"
-	_CONTENT_PLAIN   = "text/plain"
-	_CONTENT_XHTML   = "application/xhtml+xml"
-	_CONTENT_XML     = "text/xml"
-	_DEFAULT_CHARSET = "UTF-8"
+	_CONTENT_TYPE         = "Content-Type"
+	_CONTENT_BINARY       = "application/octet-stream"
+	_CONTENT_JSON         = "application/json"
+	_CONTENT_HTML         = "text/html"
+	_CONTENT_PLAIN        = "text/plain"
+	_CONTENT_XHTML        = "application/xhtml+xml"
+	_CONTENT_XML          = "text/xml"
+	_CONTENT_PROBLEM_JSON = "application/problem+json"
+	_DEFAULT_CHARSET      = "UTF-8"
 )
 
 var (
@@ -108,8 +117,26 @@ type (
 		PrefixXML []byte
 		// Allows changing of output to XHTML instead of HTML. Default is "text/html"
 		HTMLContentType string
+		// FS is the file system to load templates from instead of Directory, e.g. embed.FS.
+		// Use fs.Sub to load templates from a sub-tree.
+		FS fs.FS
+		// Addtional file systems to overwite templates of FS, in the same way as AppendDirectories.
+		AppendFS []fs.FS
 		// TemplateFileSystem is the interface for supporting any implmentation of template file system.
 		TemplateFileSystem
+		// Encoders maps media types to encoders for rendering values in additional formats.
+		// Built-in encoders of YAML, CSV, NDJSON and MessagePack are added unless overwritten.
+		// Encoders are given these options to apply the ones of their formats: NDJSON is written
+		// after PrefixJSON but never indented to keep a value per line, and YAML, CSV and MessagePack
+		// have no prefix or indentation options.
+		Encoders map[string]Encoder
+	}
+
+	// Encoder encodes values in the media type it is registered for.
+	Encoder interface {
+		// Encode writes the encoding of v to w, applying the options of its format in opt,
+		// e.g. PrefixJSON and IndentJSON for JSON based formats. The opt can be nil.
+		Encode(w io.Writer, v interface{}, opt *RenderOptions) error
 	}
 
 	// HTMLOptions is a struct for overriding some rendering Options for specific HTML call
@@ -138,8 +165,25 @@ type (
 		SetTemplatePath(string, string)
 		HasTemplateSet(string) bool
 	}
+
+	// MediaRender is implemented by renders that also render JSONP and media types of
+	// registered encoders, e.g. TplRender. It is separated from Render to keep other
+	// implementations of Render working.
+	MediaRender interface {
+		JSONP(int, string, interface{})
+		Encode(int, string, interface{})
+		MediaTypes() []string
+	}
 )
 
+// EncoderFunc is an adapter to allow the use of ordinary functions as Encoder.
+type EncoderFunc func(w io.Writer, v interface{}, opt *RenderOptions) error
+
+// Encode calls f(w, v, opt).
+func (f EncoderFunc) Encode(w io.Writer, v interface{}, opt *RenderOptions) error {
+	return f(w, v, opt)
+}
+
 // TplFile implements TemplateFile interface.
 type TplFile struct {
 	name string
@@ -171,6 +215,10 @@ type TplFileSystem struct {
 
 // NewTemplateFileSystem creates new template file system with given options.
 func NewTemplateFileSystem(opt RenderOptions, omitData bool) TplFileSystem {
+	if opt.FS != nil {
+		return newTemplateFS(opt, omitData)
+	}
+
 	fs := TplFileSystem{}
 	fs.files = make([]TemplateFile, 0, 10)
 
@@ -242,6 +290,58 @@ func NewTemplateFileSystem(opt RenderOptions, omitData bool) TplFileSystem {
 	return fs
 }
 
+// newTemplateFS creates new template file system from opt.FS,
+// files of opt.AppendFS overwrite ones of it.
+func newTemplateFS(opt RenderOptions, omitData bool) TplFileSystem {
+	tfs := TplFileSystem{}
+	tfs.files = make([]TemplateFile, 0, 10)
+
+	// File systems are composed in reverse order because later one overwrites previous ones.
+	fsyss := make([]fs.FS, 0, len(opt.AppendFS)+1)
+	for i := len(opt.AppendFS) - 1; i >= 0; i-- {
+		fsyss = append(fsyss, opt.AppendFS[i])
+	}
+	fsyss = append(fsyss, opt.FS)
+
+	// Only walk the original file system like we do for directories.
+	if err := fs.WalkDir(opt.FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if d.IsDir() {
+			return nil
+		}
+
+		ext := GetExt(name)
+		for _, extension := range opt.Extensions {
+			if ext != extension {
+				continue
+			}
+
+			var data []byte
+			if !omitData {
+				for i := range fsyss {
+					data, err = fs.ReadFile(fsyss[i], name)
+					if err == nil {
+						break
+					} else if !errors.Is(err, fs.ErrNotExist) {
+						return err
+					}
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			tfs.files = append(tfs.files, NewTplFile(name[:len(name)-len(ext)], data, ext))
+		}
+		return nil
+	}); err != nil {
+		panic("NewTemplateFileSystem: " + err.Error())
+	}
+
+	return tfs
+}
+
 func (fs TplFileSystem) ListFiles() []TemplateFile {
 	return fs.files
 }
@@ -272,7 +372,23 @@ func GetExt(s string) string {
 }
 
 func compile(opt RenderOptions) *template.Template {
-	t := template.New(opt.Directory)
+	t, err := compileTemplates(opt)
+	if err != nil {
+		// Bomb out if parse fails. We don't want any silent server starts.
+		panic(err)
+	}
+	return t
+}
+
+// compileTemplates is like compile but returns the error instead of panicking.
+func compileTemplates(opt RenderOptions) (t *template.Template, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+
+	t = template.New(opt.Directory)
 	t.Delims(opt.Delims.Left, opt.Delims.Right)
 	// Parse an initial template in case we don't have any.
 	template.Must(t.Parse("Macaron"))
@@ -286,55 +402,217 @@ func compile(opt RenderOptions) *template.Template {
 		for _, funcs := range opt.Funcs {
 			tmpl.Funcs(funcs)
 		}
-		// Bomb out if parse fails. We don't want any silent server starts.
-		template.Must(tmpl.Funcs(helperFuncs).Parse(string(f.Data())))
+		if _, err = tmpl.Funcs(helperFuncs).Parse(string(f.Data())); err != nil {
+			return nil, err
+		}
 	}
 
-	return t
+	return t, nil
+}
+
+// templateFingerprint returns a hash of names, sizes and modification times of template files
+// that can be loaded with given options, so that changes of files can be detected.
+// Templates from a custom TemplateFileSystem are not watched and always have the same fingerprint.
+func templateFingerprint(opt RenderOptions) uint64 {
+	h := fnv.New64a()
+	add := func(name string, size int64, modTime time.Time) {
+		ext := GetExt(name)
+		for _, extension := range opt.Extensions {
+			if ext == extension {
+				fmt.Fprintf(h, "%s\x00%d\x00%d\x00", name, size, modTime.UnixNano())
+				return
+			}
+		}
+	}
+
+	switch {
+	case opt.TemplateFileSystem != nil:
+	case opt.FS != nil:
+		for _, fsys := range append([]fs.FS{opt.FS}, opt.AppendFS...) {
+			_ = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return nil
+				}
+				if fi, err := d.Info(); err == nil {
+					add(name, fi.Size(), fi.ModTime())
+				}
+				return nil
+			})
+			fmt.Fprint(h, "\x00")
+		}
+	default:
+		for _, dir := range append([]string{opt.Directory}, opt.AppendDirectories...) {
+			if realDir, err := filepath.EvalSymlinks(dir); err == nil {
+				dir = realDir
+			}
+			_ = filepath.Walk(dir, func(name string, fi os.FileInfo, err error) error {
+				if err != nil || fi.IsDir() {
+					return nil
+				}
+				add(name, fi.Size(), fi.ModTime())
+				return nil
+			})
+			fmt.Fprint(h, "\x00")
+		}
+	}
+	return h.Sum64()
 }
 
 const (
 	DEFAULT_TPL_SET_NAME = "DEFAULT"
 )
 
+// templateReloadInterval is the interval between two checks of template files by the poller in development.
+var templateReloadInterval = time.Second
+
+// templateEntry is a compiled template set along with the states to reload it.
+type templateEntry struct {
+	t   *template.Template
+	opt RenderOptions
+	// pristine is a copy of t that is never executed, so that it can always be cloned.
+	pristine *template.Template
+	// clones are copies of pristine for rendering with layouts, because
+	// the "yield" and "current" functions are different in every rendering.
+	clones sync.Pool
+
+	// fingerprint is only accessed by the poller.
+	fingerprint uint64
+
+	lock sync.Mutex
+	err  error
+}
+
+func newTemplateEntry(t *template.Template, opt RenderOptions, fingerprint uint64) *templateEntry {
+	e := &templateEntry{
+		t:           t,
+		opt:         opt,
+		fingerprint: fingerprint,
+	}
+	e.pristine = template.Must(t.Clone())
+	e.clones.New = func() interface{} {
+		return template.Must(e.pristine.Clone())
+	}
+	return e
+}
+
 // TemplateSet represents a template set of type *template.Template.
 type TemplateSet struct {
-	lock sync.RWMutex
-	sets map[string]*template.Template
-	dirs map[string]string
+	lock    sync.RWMutex
+	entries map[string]*templateEntry
 }
 
 // NewTemplateSet initializes a new empty template set.
 func NewTemplateSet() *TemplateSet {
 	return &TemplateSet{
-		sets: make(map[string]*template.Template),
-		dirs: make(map[string]string),
+		entries: make(map[string]*templateEntry),
 	}
 }
 
 func (ts *TemplateSet) Set(name string, opt *RenderOptions) *template.Template {
+	fingerprint := templateFingerprint(*opt)
 	t := compile(*opt)
+	e := newTemplateEntry(t, *opt, fingerprint)
 
 	ts.lock.Lock()
 	defer ts.lock.Unlock()
 
-	ts.sets[name] = t
-	ts.dirs[name] = opt.Directory
+	ts.entries[name] = e
 	return t
 }
 
-func (ts *TemplateSet) Get(name string) *template.Template {
+func (ts *TemplateSet) entry(name string) *templateEntry {
 	ts.lock.RLock()
 	defer ts.lock.RUnlock()
 
-	return ts.sets[name]
+	return ts.entries[name]
+}
+
+func (ts *TemplateSet) Get(name string) *template.Template {
+	if e := ts.entry(name); e != nil {
+		return e.t
+	}
+	return nil
 }
 
 func (ts *TemplateSet) GetDir(name string) string {
+	if e := ts.entry(name); e != nil {
+		return e.opt.Directory
+	}
+	return ""
+}
+
+// loadErr returns the error of last compilation of the template set with given name.
+func (ts *TemplateSet) loadErr(name string) error {
+	e := ts.entry(name)
+	if e == nil {
+		return nil
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.err
+}
+
+// poll recompiles template sets whose template files have been changed since last compilation.
+// Files are checked without holding any lock, so that rendering is never blocked by the check.
+// The template set compiled previously is kept until errors are fixed.
+func (ts *TemplateSet) poll() {
 	ts.lock.RLock()
-	defer ts.lock.RUnlock()
+	entries := maps.Clone(ts.entries)
+	ts.lock.RUnlock()
+
+	for name, e := range entries {
+		fingerprint := templateFingerprint(e.opt)
+		if fingerprint == e.fingerprint {
+			continue
+		}
+		e.fingerprint = fingerprint
+
+		t, err := compileTemplates(e.opt)
+		e.lock.Lock()
+		e.err = err
+		e.lock.Unlock()
+		if err != nil {
+			continue
+		}
+
+		ts.lock.Lock()
+		// Do not overwrite the template set that has been replaced in the meantime.
+		if ts.entries[name] == e {
+			ts.entries[name] = newTemplateEntry(t, e.opt, fingerprint)
+		}
+		ts.lock.Unlock()
+	}
+}
+
+// startPolling polls template files of the template set in background every given interval.
+// The poller stops once the template set is no longer used.
+func (ts *TemplateSet) startPolling(interval time.Duration) {
+	wp := weak.Make(ts)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ts := wp.Value()
+			if ts == nil {
+				return
+			}
+			ts.poll()
+		}
+	}()
+}
 
-	return ts.dirs[name]
+// clone returns a copy of the template set with given name for exclusive use,
+// the release function must be called once the copy is no longer used.
+func (ts *TemplateSet) clone(name string) (*template.Template, func()) {
+	e := ts.entry(name)
+	if e == nil {
+		return nil, func() {}
+	}
+	t := e.clones.Get().(*template.Template)
+	return t, func() { e.clones.Put(t) }
 }
 
 func prepareRenderOptions(options []RenderOptions) RenderOptions {
@@ -354,6 +632,13 @@ func prepareRenderOptions(options []RenderOptions) RenderOptions {
 		opt.HTMLContentType = _CONTENT_HTML
 	}
 
+	// Copy encoders to not modify the map of caller.
+	encoders := defaultEncoders()
+	for typ, enc := range opt.Encoders {
+		encoders[typ] = enc
+	}
+	opt.Encoders = encoders
+
 	return opt
 }
 
@@ -377,7 +662,7 @@ func ParseTplSet(tplSet string) (tplName string, tplDir string) {
 	return tplName, tplDir
 }
 
-func renderHandler(opt RenderOptions, tplSets []string) Handler {
+func renderHandler(opt RenderOptions, tplSets []string, fsSets map[string]fs.FS) Handler {
 	cs := PrepareCharset(opt.Charset)
 	ts := NewTemplateSet()
 	ts.Set(DEFAULT_TPL_SET_NAME, &opt)
@@ -387,10 +672,23 @@ func renderHandler(opt RenderOptions, tplSets []string) Handler {
 		tplName, tplDir := ParseTplSet(tplSet)
 		tmpOpt = opt
 		tmpOpt.Directory = tplDir
+		tmpOpt.FS = nil
 		ts.Set(tplName, &tmpOpt)
 	}
+	for tplName, fsys := range fsSets {
+		if fsys == nil {
+			panic("nil file system of template set " + tplName)
+		}
+		tmpOpt = opt
+		tmpOpt.FS = fsys
+		ts.Set(tplName, &tmpOpt)
+	}
+	if Env == DEV {
+		ts.startPolling(templateReloadInterval)
+	}
+	provided := []reflect.Type{reflect.TypeOf((*Render)(nil)).Elem()}
 
-	return func(ctx *Context) {
+	return &providerHandler{provided: provided, handle: func(ctx *Context) {
 		r := &TplRender{
 			ResponseWriter:  ctx.Resp,
 			TemplateSet:     ts,
@@ -406,7 +704,7 @@ func renderHandler(opt RenderOptions, tplSets []string) Handler {
 
 		ctx.Render = r
 		ctx.MapTo(r, (*Render)(nil))
-	}
+	}}
 }
 
 // Renderer is a Middleware that maps a macaron.Render service into the Macaron handler chain.
@@ -414,14 +712,20 @@ func renderHandler(opt RenderOptions, tplSets []string) Handler {
 // HTML rendering. The default directory for templates is "templates" and the default
 // file extension is ".tmpl" and ".html".
 //
-// If MACARON_ENV is set to "" or "development" then templates will be recompiled on every request. For more performance, set the
-// MACARON_ENV environment variable to "production".
+// If MACARON_ENV is set to "" or "development" then template files are polled for changes every second in
+// background and recompiled once they are changed, and errors of recompilation are shown on the page. For more performance, set the MACARON_ENV environment variable to "production".
 func Renderer(options ...RenderOptions) Handler {
-	return renderHandler(prepareRenderOptions(options), []string{})
+	return renderHandler(prepareRenderOptions(options), []string{}, nil)
 }
 
 func Renderers(options RenderOptions, tplSets ...string) Handler {
-	return renderHandler(prepareRenderOptions([]RenderOptions{options}), tplSets)
+	return renderHandler(prepareRenderOptions([]RenderOptions{options}), tplSets, nil)
+}
+
+// RenderersFS is like Renderers but loads each template set from a file system,
+// e.g. a sub-tree of embed.FS returned by fs.Sub, keyed by the template set name.
+func RenderersFS(options RenderOptions, tplSets map[string]fs.FS) Handler {
+	return renderHandler(prepareRenderOptions([]RenderOptions{options}), nil, tplSets)
 }
 
 type TplRender struct {
@@ -475,6 +779,29 @@ func (r *TplRender) JSONString(v interface{}) (string, error) {
 	return string(result), nil
 }
 
+var jsonpCallbackPattern = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+// JSONP renders v as JSON wrapped by given callback function, it responds with 400
+// when the callback is not a valid JavaScript identifier or property path.
+func (r *TplRender) JSONP(status int, callback string, v interface{}) {
+	if !jsonpCallbackPattern.MatchString(callback) {
+		http.Error(r, "invalid JSONP callback", http.StatusBadRequest)
+		return
+	}
+
+	result, err := r.JSONString(v)
+	if err != nil {
+		http.Error(r, err.Error(), 500)
+		return
+	}
+
+	r.Header().Set(_CONTENT_TYPE, _CONTENT_JSONP+r.CompiledCharset)
+	r.Header().Set("X-Content-Type-Options", "nosniff")
+	r.WriteHeader(status)
+	// The leading comment prevents the response being interpreted as other content types.
+	_, _ = r.Write([]byte("/**/" + callback + "(" + result + ");"))
+}
+
 func (r *TplRender) XML(status int, v interface{}) {
 	var result []byte
 	var err error
@@ -497,6 +824,43 @@ func (r *TplRender) XML(status int, v interface{}) {
 	_, _ = r.Write(result)
 }
 
+// Encode renders v by the encoder registered for given media type in RenderOptions.Encoders.
+func (r *TplRender) Encode(status int, mediaType string, v interface{}) {
+	enc, ok := r.Opt.Encoders[mediaType]
+	if !ok {
+		http.Error(r, "no encoder registered for media type "+mediaType, http.StatusInternalServerError)
+		return
+	}
+
+	buf := bufpool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		bufpool.Put(buf)
+	}()
+	if err := enc.Encode(buf, v, r.Opt); err != nil {
+		http.Error(r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := mediaType
+	if _, ok := enc.(BinaryEncoder); !ok {
+		contentType += r.CompiledCharset
+	}
+	r.Header().Set(_CONTENT_TYPE, contentType)
+	r.WriteHeader(status)
+	_, _ = buf.WriteTo(r)
+}
+
+// MediaTypes returns sorted media types of all registered encoders.
+func (r *TplRender) MediaTypes() []string {
+	types := make([]string, 0, len(r.Opt.Encoders))
+	for typ := range r.Opt.Encoders {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
 func (r *TplRender) data(status int, contentType string, v []byte) {
 	if r.Header().Get(_CONTENT_TYPE) == "" {
 		r.Header().Set(_CONTENT_TYPE, contentType)
@@ -533,12 +897,13 @@ func (r *TplRender) addYield(t *template.Template, tplName string, data interfac
 }
 
 func (r *TplRender) renderBytes(setName, tplName string, data interface{}, htmlOpt ...HTMLOptions) (*bytes.Buffer, error) {
-	t := r.Get(setName)
 	if Env == DEV {
-		opt := *r.Opt
-		opt.Directory = r.GetDir(setName)
-		t = r.Set(setName, &opt)
+		if err := r.loadErr(setName); err != nil {
+			return nil, err
+		}
 	}
+
+	t := r.Get(setName)
 	if t == nil {
 		return nil, fmt.Errorf("html/template: template \"%s\" is undefined", tplName)
 	}
@@ -546,6 +911,9 @@ func (r *TplRender) renderBytes(setName, tplName string, data interface{}, htmlO
 	opt := r.prepareHTMLOptions(htmlOpt)
 
 	if len(opt.Layout) > 0 {
+		var release func()
+		t, release = r.clone(setName)
+		defer release()
 		r.addYield(t, tplName, data)
 		tplName = opt.Layout
 	}
@@ -634,6 +1002,7 @@ func (r *TplRender) SetTemplatePath(setName, dir string) {
 	}
 	opt := *r.Opt
 	opt.Directory = dir
+	opt.FS = nil
 	r.Set(setName, &opt)
 }
 
@@ -665,6 +1034,10 @@ func (r *DummyRender) JSONString(interface{}) (string, error) {
 	return "", nil
 }
 
+func (r *DummyRender) JSONP(int, string, interface{}) {
+	renderNotRegistered()
+}
+
 func (r *DummyRender) RawData(int, []byte) {
 	renderNotRegistered()
 }
@@ -705,6 +1078,15 @@ func (r *DummyRender) XML(int, interface{}) {
 	renderNotRegistered()
 }
 
+func (r *DummyRender) Encode(int, string, interface{}) {
+	renderNotRegistered()
+}
+
+func (r *DummyRender) MediaTypes() []string {
+	renderNotRegistered()
+	return nil
+}
+
 func (r *DummyRender) Error(int, ...string) {
 	renderNotRegistered()
 }
@@ -16,17 +16,27 @@
 package macaron
 
 import (
+	"embed"
 	"encoding/xml"
+	"fmt"
 	"html/template"
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"runtime"
+	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
 
+//go:embed fixtures/basic fixtures/basic2
+var fixturesFS embed.FS
+
 type Greeting struct {
 	One string `json:"one"`
 	Two string `json:"two"`
@@ -635,6 +645,164 @@ func Test_Render_AppendDirectories(t *testing.T) {
 	})
 }
 
+func Test_Render_FileSystem(t *testing.T) {
+	basic, err := fs.Sub(fixturesFS, "fixtures/basic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	basic2, err := fs.Sub(fixturesFS, "fixtures/basic2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	render := func(m *Macaron, url string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", url, nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		return resp
+	}
+
+	Convey("Render templates from embedded file system", t, func() {
+		m := Classic()
+		m.Use(RenderersFS(RenderOptions{
+			Directory: "404",
+			FS:        basic,
+		}, map[string]fs.FS{"basic2": basic2}))
+		m.Get("/", func(r Render) {
+			r.HTML(200, "admin/index", "jeremy")
+		})
+		m.Get("/layout", func(r Render) {
+			r.HTML(200, "content", "jeremy", HTMLOptions{Layout: "layout"})
+		})
+		m.Get("/set", func(r Render) {
+			r.HTMLSet(200, "basic2", "hello", "jeremy")
+		})
+
+		So(render(m, "/").Body.String(), ShouldEqual, "<h1>Admin jeremy</h1>")
+		So(render(m, "/layout").Body.String(), ShouldEqual, "head<h1>jeremy</h1>foot")
+		So(render(m, "/set").Body.String(), ShouldEqual, "<h1>What's up, jeremy</h1>")
+	})
+
+	Convey("Render templates from overlay file systems", t, func() {
+		m := Classic()
+		m.Use(Renderer(RenderOptions{
+			FS: basic,
+			AppendFS: []fs.FS{
+				fstest.MapFS{
+					"hello.tmpl":   {Data: []byte("<h1>Overlay {{.}}</h1>")},
+					"content.tmpl": {Data: []byte("<h1>Overlay {{.}}</h1>")},
+					"new.tmpl":     {Data: []byte("<h1>Not in original</h1>")},
+				},
+				fstest.MapFS{
+					"hello.tmpl": {Data: []byte("<h1>Last overlay {{.}}</h1>")},
+				},
+			},
+		}))
+		m.Get("/:name", func(ctx *Context) {
+			ctx.HTML(200, ctx.Params("name"), "world")
+		})
+
+		So(render(m, "/hello").Body.String(), ShouldEqual, "<h1>Last overlay world</h1>")
+		So(render(m, "/content").Body.String(), ShouldEqual, "<h1>Overlay world</h1>")
+		So(render(m, "/delims").Code, ShouldEqual, http.StatusOK)
+		So(render(m, "/new").Code, ShouldEqual, http.StatusInternalServerError)
+	})
+
+	Convey("Render template set with nil file system", t, func() {
+		defer func() {
+			So(recover(), ShouldNotBeNil)
+		}()
+		RenderersFS(RenderOptions{FS: basic}, map[string]fs.FS{"nil": nil})
+	})
+}
+
+func Test_Render_Reload(t *testing.T) {
+	Convey("Reload templates once files are changed", t, func() {
+		defaultInterval := templateReloadInterval
+		templateReloadInterval = 10 * time.Millisecond
+		defer func() { templateReloadInterval = defaultInterval }()
+
+		dir := t.TempDir()
+		modTime := time.Now()
+		writeTemplate := func(content string) {
+			name := filepath.Join(dir, "hello.tmpl")
+			So(os.WriteFile(name, []byte(content), 0644), ShouldBeNil)
+			modTime = modTime.Add(time.Second)
+			So(os.Chtimes(name, modTime, modTime), ShouldBeNil)
+		}
+		writeTemplate("<h1>Hello {{.}}</h1>")
+
+		m := Classic()
+		m.Use(Renderer(RenderOptions{
+			Directory: dir,
+		}))
+		m.Get("/", func(r Render) {
+			r.HTML(200, "hello", "world")
+		})
+		render := func() *httptest.ResponseRecorder {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/", nil)
+			So(err, ShouldBeNil)
+			m.ServeHTTP(resp, req)
+			return resp
+		}
+		// Templates are reloaded by the poller in background.
+		renderUntil := func(done func(*httptest.ResponseRecorder) bool) *httptest.ResponseRecorder {
+			resp := render()
+			for i := 0; i < 100 && !done(resp); i++ {
+				time.Sleep(templateReloadInterval)
+				resp = render()
+			}
+			return resp
+		}
+		bodyIs := func(body string) func(*httptest.ResponseRecorder) bool {
+			return func(resp *httptest.ResponseRecorder) bool { return resp.Body.String() == body }
+		}
+		So(render().Body.String(), ShouldEqual, "<h1>Hello world</h1>")
+
+		writeTemplate("<h1>Hi {{.}}</h1>")
+		So(renderUntil(bodyIs("<h1>Hi world</h1>")).Body.String(), ShouldEqual, "<h1>Hi world</h1>")
+
+		writeTemplate("<h1>Hi {{.}</h1>")
+		resp := renderUntil(func(resp *httptest.ResponseRecorder) bool { return resp.Code == http.StatusInternalServerError })
+		So(resp.Code, ShouldEqual, http.StatusInternalServerError)
+		So(resp.Body.String(), ShouldContainSubstring, "template: hello:1")
+
+		writeTemplate("<h1>Bye {{.}}</h1>")
+		So(renderUntil(bodyIs("<h1>Bye world</h1>")).Body.String(), ShouldEqual, "<h1>Bye world</h1>")
+	})
+
+	Convey("Render layouts concurrently", t, func() {
+		m := Classic()
+		m.Use(Renderer(RenderOptions{
+			Directory: "fixtures/basic",
+			Layout:    "layout",
+		}))
+		m.Get("/:name", func(ctx *Context) {
+			ctx.HTML(200, "content", ctx.Params("name"))
+		})
+
+		var wg sync.WaitGroup
+		bodies := make([]string, 20)
+		for i := range bodies {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				resp := httptest.NewRecorder()
+				req, _ := http.NewRequest("GET", fmt.Sprintf("/user%d", i), nil)
+				m.ServeHTTP(resp, req)
+				bodies[i] = resp.Body.String()
+			}(i)
+		}
+		wg.Wait()
+
+		for i := range bodies {
+			So(bodies[i], ShouldEqual, fmt.Sprintf("head<h1>user%d</h1>foot", i))
+		}
+	})
+}
+
 func Test_GetExt(t *testing.T) {
 	Convey("Get extension", t, func() {
 		So(GetExt("test"), ShouldBeBlank)
@@ -668,6 +836,18 @@ func Test_dummyRender(t *testing.T) {
 			defer shouldPanic()
 			_, _ = ctx.JSONString(nil)
 		})
+		m.Get("/jsonp", func(ctx *Context) {
+			defer shouldPanic()
+			ctx.JSONP(0, "", nil)
+		})
+		m.Get("/encode", func(ctx *Context) {
+			defer shouldPanic()
+			ctx.Encode(0, "", nil)
+		})
+		m.Get("/mediatypes", func(ctx *Context) {
+			defer shouldPanic()
+			ctx.MediaTypes()
+		})
 		m.Get("/rawdata", func(ctx *Context) {
 			defer shouldPanic()
 			ctx.RawData(0, nil)
@@ -724,6 +904,9 @@ func Test_dummyRender(t *testing.T) {
 		performRequest("GET", "/set_response_writer")
 		performRequest("GET", "/json")
 		performRequest("GET", "/jsonstring")
+		performRequest("GET", "/jsonp")
+		performRequest("GET", "/encode")
+		performRequest("GET", "/mediatypes")
 		performRequest("GET", "/rawdata")
 		performRequest("GET", "/jsonstring")
 		performRequest("GET", "/plaintext")
@@ -0,0 +1,119 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"reflect"
+)
+
+// DefaultRequestIDHeader is the default header to accept and echo request IDs.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the maximal length of accepted inbound request IDs.
+const maxRequestIDLength = 200
+
+// RequestIDOptions represents a struct for specifying configuration options for the RequestID middleware.
+type RequestIDOptions struct {
+	// Header to accept inbound request IDs from and echo request IDs on. Default is "X-Request-ID".
+	Header string
+	// IgnoreInbound disables accepting request IDs from clients, e.g. when they are not trusted.
+	IgnoreInbound bool
+	// Generator generates a new request ID. Default is NewRequestID.
+	Generator func() string
+}
+
+// NewRequestID generates a random request ID of 32 hexadecimal characters.
+func NewRequestID() string {
+	p := make([]byte, 16)
+	if _, err := rand.Read(p); err != nil {
+		panic("NewRequestID: " + err.Error())
+	}
+	return hex.EncodeToString(p)
+}
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request ID stored in given context by the RequestID middleware,
+// or an empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID returns true if the inbound request ID is safe to use in logs and headers.
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func prepareRequestIDOptions(options []RequestIDOptions) RequestIDOptions {
+	var opt RequestIDOptions
+	if len(options) > 0 {
+		opt = options[0]
+	}
+
+	// Defaults.
+	if len(opt.Header) == 0 {
+		opt.Header = DefaultRequestIDHeader
+	}
+	if opt.Generator == nil {
+		opt.Generator = NewRequestID
+	}
+	return opt
+}
+
+// requestIDInvoker is the handler of RequestID middleware which sets the request ID of the
+// context once. The Macaron instance records the one it uses, so that Context.RequestID can
+// set the request ID before the middleware runs.
+type requestIDInvoker func(ctx *Context)
+
+func (invoke requestIDInvoker) Invoke(params []interface{}) ([]reflect.Value, error) {
+	invoke(params[0].(*Context))
+	return nil, nil
+}
+
+// RequestID returns a middleware handler that identifies every request. It accepts the request ID
+// from the inbound header if valid or generates a new one, then stores it on the Context and in
+// the context of request, and echoes it on the response header. Logger and Recovery include the
+// request ID in their output even when they run first, e.g. in Classic, see Context.RequestID.
+func RequestID(options ...RequestIDOptions) Handler {
+	opt := prepareRequestIDOptions(options)
+
+	return requestIDInvoker(func(ctx *Context) {
+		if len(ctx.requestID) > 0 {
+			return
+		}
+
+		id := ctx.Req.Header.Get(opt.Header)
+		if opt.IgnoreInbound || !validRequestID(id) {
+			id = opt.Generator()
+		}
+
+		ctx.requestID = id
+		ctx.Req.Request = ctx.Req.WithContext(context.WithValue(ctx.Req.Context(), requestIDKey{}, id))
+		ctx.Map(ctx.Req.Request)
+		ctx.Resp.Header().Set(opt.Header, id)
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_RequestID(t *testing.T) {
+	request := func(m *Macaron, header, id string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/", nil)
+		So(err, ShouldBeNil)
+		if len(id) > 0 {
+			req.Header.Set(header, id)
+		}
+		m.ServeHTTP(resp, req)
+		return resp
+	}
+
+	Convey("Accept or generate request ID", t, func() {
+		m := New()
+		m.Use(RequestID())
+		m.Get("/", func(ctx *Context, req *http.Request) string {
+			So(RequestIDFromContext(ctx.Req.Context()), ShouldEqual, ctx.RequestID())
+			So(RequestIDFromContext(req.Context()), ShouldEqual, ctx.RequestID())
+			return ctx.RequestID()
+		})
+
+		resp := request(m, DefaultRequestIDHeader, "inbound-id")
+		So(resp.Header().Get(DefaultRequestIDHeader), ShouldEqual, "inbound-id")
+		So(resp.Body.String(), ShouldEqual, "inbound-id")
+
+		resp = request(m, DefaultRequestIDHeader, "")
+		So(resp.Header().Get(DefaultRequestIDHeader), ShouldHaveLength, 32)
+		So(resp.Body.String(), ShouldEqual, resp.Header().Get(DefaultRequestIDHeader))
+
+		for _, id := range []string{"bad id", "badé", strings.Repeat("a", 201)} {
+			resp = request(m, DefaultRequestIDHeader, id)
+			So(resp.Header().Get(DefaultRequestIDHeader), ShouldHaveLength, 32)
+		}
+	})
+
+	Convey("Use custom header and generator", t, func() {
+		m := New()
+		m.Use(RequestID(RequestIDOptions{
+			Header:        "X-Trace-ID",
+			IgnoreInbound: true,
+			Generator:     func() string { return "generated" },
+		}))
+		m.Get("/", func(ctx *Context) string { return ctx.RequestID() })
+
+		resp := request(m, "X-Trace-ID", "inbound-id")
+		So(resp.Header().Get("X-Trace-ID"), ShouldEqual, "generated")
+		So(resp.Body.String(), ShouldEqual, "generated")
+	})
+
+	Convey("Include request ID in Logger and Recovery output", t, func() {
+		var buf bytes.Buffer
+		m := NewWithLogger(&buf)
+		m.Use(Logger())
+		m.Use(RequestID())
+		m.Use(Recovery())
+		m.Get("/", func() { panic("here is a panic!") })
+
+		resp := request(m, DefaultRequestIDHeader, "panic-id")
+		So(resp.Code, ShouldEqual, http.StatusInternalServerError)
+		So(resp.Header().Get(DefaultRequestIDHeader), ShouldEqual, "panic-id")
+		So(buf.String(), ShouldContainSubstring, "PANIC [panic-id]: here is a panic!")
+		So(strings.Count(buf.String(), "[panic-id]"), ShouldEqual, 3)
+	})
+
+	Convey("Include request ID in Logger output when RequestID is used after it", t, func() {
+		var buf bytes.Buffer
+		m := NewWithLogger(&buf)
+		m.Use(Logger())
+		m.Use(RequestID(RequestIDOptions{Header: "X-Trace-ID"}))
+		m.Get("/", func(ctx *Context) string { return ctx.RequestID() })
+
+		resp := request(m, "X-Trace-ID", "classic-id")
+		So(resp.Header().Get("X-Trace-ID"), ShouldEqual, "classic-id")
+		So(resp.Body.String(), ShouldEqual, "classic-id")
+		So(strings.Split(buf.String(), "\n")[0], ShouldContainSubstring, "Started")
+		So(strings.Split(buf.String(), "\n")[0], ShouldEndWith, "[classic-id]")
+		So(strings.Count(buf.String(), "[classic-id]"), ShouldEqual, 2)
+
+		buf.Reset()
+		resp = request(m, "X-Trace-ID", "")
+		So(resp.Header().Get("X-Trace-ID"), ShouldHaveLength, 32)
+		So(resp.Body.String(), ShouldEqual, resp.Header().Get("X-Trace-ID"))
+		So(buf.String(), ShouldContainSubstring, "["+resp.Body.String()+"]")
+	})
+
+	Convey("Get request ID before the middleware runs", t, func() {
+		m := New()
+		m.Use(func(ctx *Context) {
+			ctx.Data["id"] = ctx.RequestID()
+		})
+		m.Use(RequestID())
+		m.Get("/", func(ctx *Context) string {
+			So(RequestIDFromContext(ctx.Req.Context()), ShouldEqual, ctx.RequestID())
+			return ctx.Data["id"].(string) + " " + ctx.RequestID()
+		})
+
+		resp := request(m, DefaultRequestIDHeader, "early-id")
+		So(resp.Body.String(), ShouldEqual, "early-id early-id")
+		So(resp.Header().Get(DefaultRequestIDHeader), ShouldEqual, "early-id")
+	})
+
+	Convey("Context without request ID", t, func() {
+		m := New()
+		m.Get("/", func(ctx *Context) string { return ctx.RequestID() })
+		So(request(m, DefaultRequestIDHeader, "inbound-id").Body.String(), ShouldBeBlank)
+	})
+}
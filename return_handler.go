@@ -16,8 +16,13 @@
 package macaron
 
 import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
 	"net/http"
 	"reflect"
+	"slices"
 
 	"github.com/go-macaron/inject"
 )
@@ -28,49 +33,136 @@ import (
 // that are passed into this function.
 type ReturnHandler func(*Context, []reflect.Value)
 
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 func canDeref(val reflect.Value) bool {
 	return val.Kind() == reflect.Interface || val.Kind() == reflect.Ptr
 }
 
-func isError(val reflect.Value) bool {
-	_, ok := val.Interface().(error)
-	return ok
-}
-
 func isByteSlice(val reflect.Value) bool {
 	return val.Kind() == reflect.Slice && val.Type().Elem().Kind() == reflect.Uint8
 }
 
+// writeStatus writes the status if it is given.
+func writeStatus(resp http.ResponseWriter, status int) {
+	if status > 0 {
+		resp.WriteHeader(status)
+	}
+}
+
+// writeValue encodes the returned value to the response with given status, where 0 means default.
+func writeValue(ctx *Context, resp http.ResponseWriter, status int, respVal reflect.Value) {
+	if canDeref(respVal) && respVal.IsNil() {
+		writeStatus(resp, status)
+		return
+	}
+
+	switch v := respVal.Interface().(type) {
+	case error:
+		ctx.internalServerError(ctx, v)
+		return
+	case http.Handler:
+		v.ServeHTTP(resp, ctx.Req.Request)
+		return
+	case io.Reader:
+		writeStatus(resp, status)
+		_, _ = io.Copy(resp, v)
+		if c, ok := v.(io.Closer); ok {
+			_ = c.Close()
+		}
+		return
+	}
+
+	for canDeref(respVal) {
+		respVal = respVal.Elem()
+	}
+	switch {
+	case isByteSlice(respVal):
+		writeStatus(resp, status)
+		_, _ = resp.Write(respVal.Bytes())
+	case respVal.Kind() == reflect.String:
+		writeStatus(resp, status)
+		_, _ = resp.Write([]byte(respVal.String()))
+	case respVal.Kind() == reflect.Struct, respVal.Kind() == reflect.Map,
+		respVal.Kind() == reflect.Slice, respVal.Kind() == reflect.Array:
+		if status == 0 {
+			status = http.StatusOK
+		}
+		writeEncoded(ctx, resp, status, respVal.Interface())
+	default:
+		writeStatus(resp, status)
+		_, _ = fmt.Fprint(resp, respVal.Interface())
+	}
+}
+
+// xmlEncodable returns true if v can be encoded as an XML document, which must have a single
+// root element, so that only structs and values implementing xml.Marshaler are encodable.
+func xmlEncodable(v interface{}) bool {
+	if _, ok := v.(xml.Marshaler); !ok && reflect.ValueOf(v).Kind() != reflect.Struct {
+		return false
+	}
+	return xml.NewEncoder(io.Discard).Encode(v) == nil
+}
+
+// writeEncoded encodes v by the Render in the media type negotiated by the Accept header,
+// XML is only offered when v is encodable as XML. It encodes in JSON if Render is not
+// registered or the Accept header lists none of offered media types but by wildcards.
+func writeEncoded(ctx *Context, resp http.ResponseWriter, status int, v interface{}) {
+	if _, ok := ctx.Render.(*DummyRender); !ok {
+		offers := ctx.negotiateOffers(NegotiateOptions{})
+		if !xmlEncodable(v) {
+			offers = slices.DeleteFunc(offers, func(offer string) bool {
+				return offer == "application/xml" || offer == _CONTENT_XML
+			})
+		}
+		if acceptsExplicitly(ctx.Req.Header.Get("Accept"), offers) {
+			ctx.Negotiate(status, v, NegotiateOptions{Offers: offers})
+		} else {
+			ctx.Render.JSON(status, v)
+		}
+		return
+	}
+
+	p, err := json.Marshal(v)
+	if err != nil {
+		ctx.internalServerError(ctx, err)
+		return
+	}
+	resp.Header().Set(_CONTENT_TYPE, _CONTENT_JSON+"; charset="+_DEFAULT_CHARSET)
+	resp.WriteHeader(status)
+	_, _ = resp.Write(p)
+}
+
+// defaultReturnHandler writes returned values to the response. Supported forms are a single
+// value, (int, value), (value, error), (int, error) and (int, value, error), where the int is the status.
+// Strings and byte slices are written as is, structs, maps and slices are encoded by Render,
+// io.Reader is streamed, http.Handler is served and non-nil error is passed to the
+// InternalServerError handler. Nothing is written for nil values without status.
 func defaultReturnHandler() ReturnHandler {
 	return func(ctx *Context, vals []reflect.Value) {
 		rv := ctx.GetVal(inject.InterfaceOf((*http.ResponseWriter)(nil)))
 		resp := rv.Interface().(http.ResponseWriter)
-		var respVal reflect.Value
-		if len(vals) > 1 && vals[0].Kind() == reflect.Int {
-			resp.WriteHeader(int(vals[0].Int()))
-			respVal = vals[1]
-		} else if len(vals) > 0 {
-			respVal = vals[0]
-
-			if isError(respVal) {
-				err := respVal.Interface().(error)
-				if err != nil {
-					ctx.internalServerError(ctx, err)
-				}
+
+		// The trailing error takes precedence over other values.
+		withError := false
+		if n := len(vals); n > 1 && vals[n-1].Type() == errorType {
+			if !vals[n-1].IsNil() {
+				ctx.internalServerError(ctx, vals[n-1].Interface().(error))
 				return
-			} else if canDeref(respVal) {
-				if respVal.IsNil() {
-					return // Ignore nil error
-				}
 			}
+			vals = vals[:n-1]
+			withError = true
 		}
-		if canDeref(respVal) {
-			respVal = respVal.Elem()
+
+		status := 0
+		if (len(vals) > 1 || withError) && vals[0].Kind() == reflect.Int {
+			status = int(vals[0].Int())
+			vals = vals[1:]
 		}
-		if isByteSlice(respVal) {
-			_, _ = resp.Write(respVal.Bytes())
-		} else {
-			_, _ = resp.Write([]byte(respVal.String()))
+		if len(vals) == 0 {
+			writeStatus(resp, status)
+			return
 		}
+		writeValue(ctx, resp, status, vals[0])
 	}
 }
@@ -16,9 +16,11 @@ package macaron
 
 import (
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -31,6 +33,9 @@ func (l r1Invoker) Invoke(p []interface{}) ([]reflect.Value, error) {
 	return []reflect.Value{reflect.ValueOf(ret), reflect.ValueOf(str)}, nil
 }
 
+// browserAccept is the Accept header sent by browsers for navigation.
+const browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
 func Test_Return_Handler(t *testing.T) {
 	Convey("Return with status and body", t, func() {
 		m := New()
@@ -122,4 +127,140 @@ func Test_Return_Handler(t *testing.T) {
 
 		So(resp.Body.String(), ShouldEqual, "hello world")
 	})
+
+	Convey("Return with encoded values", t, func() {
+		type user struct {
+			Name string `json:"name" xml:"name"`
+		}
+		request := func(m *Macaron, accept string) *httptest.ResponseRecorder {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/", nil)
+			So(err, ShouldBeNil)
+			req.Header.Set("Accept", accept)
+			m.ServeHTTP(resp, req)
+			return resp
+		}
+
+		m := New()
+		m.Get("/", func() (int, *user) {
+			return http.StatusCreated, &user{"unknwon"}
+		})
+		resp := request(m, "")
+		So(resp.Code, ShouldEqual, http.StatusCreated)
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldEqual, "application/json; charset=UTF-8")
+		So(resp.Body.String(), ShouldEqual, `{"name":"unknwon"}`)
+
+		m = New()
+		m.Use(Renderer())
+		m.Get("/", func() ([]user, error) {
+			return []user{{"unknwon"}}, nil
+		})
+		resp = request(m, "")
+		So(resp.Code, ShouldEqual, http.StatusOK)
+		So(resp.Body.String(), ShouldEqual, `[{"name":"unknwon"}]`)
+		resp = request(m, "application/x-ndjson")
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldEqual, "application/x-ndjson; charset=UTF-8")
+		So(resp.Body.String(), ShouldEqual, "{\"name\":\"unknwon\"}\n")
+
+		m = New()
+		m.Use(Renderer())
+		m.Get("/", func() map[string]int {
+			return map[string]int{"count": 1}
+		})
+		resp = request(m, "text/html, application/json;q=0.5")
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldEqual, "application/json; charset=UTF-8")
+		So(resp.Body.String(), ShouldEqual, `{"count":1}`)
+	})
+
+	Convey("Return with values encodable in XML or not", t, func() {
+		type user struct {
+			Name string `json:"name" xml:"name"`
+		}
+
+		m := New()
+		m.Use(Renderer())
+		m.Get("/map", func() map[string]int { return map[string]int{"count": 1} })
+		m.Get("/slice", func() []string { return []string{"a", "b"} })
+		m.Get("/struct", func() user { return user{"unknwon"} })
+
+		cases := []struct {
+			url, accept string
+			contentType string
+			body        string
+		}{
+			{"/map", browserAccept, _CONTENT_JSON, `{"count":1}`},
+			{"/slice", browserAccept, _CONTENT_JSON, `["a","b"]`},
+			{"/slice", "text/html", _CONTENT_JSON, `["a","b"]`},
+			{"/slice", "text/*", _CONTENT_JSON, `["a","b"]`},
+			{"/struct", browserAccept, _CONTENT_XML, `<user><name>unknwon</name></user>`},
+			{"/struct", "text/html", _CONTENT_JSON, `{"name":"unknwon"}`},
+		}
+		for _, c := range cases {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", c.url, nil)
+			So(err, ShouldBeNil)
+			req.Header.Set("Accept", c.accept)
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+			So(resp.Header().Get(_CONTENT_TYPE), ShouldStartWith, c.contentType)
+			So(resp.Body.String(), ShouldEqual, c.body)
+		}
+	})
+
+	Convey("Return with tuples of error", t, func() {
+		m := New()
+		m.Get("/value", func() (string, error) {
+			return "", NewHTTPError(http.StatusNotFound)
+		})
+		m.Get("/status", func() (int, string, error) {
+			return http.StatusAccepted, "accepted", nil
+		})
+		m.Get("/empty", func() (int, error) {
+			return http.StatusNoContent, nil
+		})
+		m.Get("/int", func() int {
+			return 42
+		})
+
+		for url, expect := range map[string]struct {
+			code int
+			body string
+		}{
+			"/value":  {http.StatusNotFound, "Not Found\n"},
+			"/status": {http.StatusAccepted, "accepted"},
+			"/empty":  {http.StatusNoContent, ""},
+			"/int":    {http.StatusOK, "42"},
+		} {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", url, nil)
+			So(err, ShouldBeNil)
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, expect.code)
+			So(resp.Body.String(), ShouldEqual, expect.body)
+		}
+	})
+
+	Convey("Return with reader and handler", t, func() {
+		m := New()
+		m.Get("/reader", func() (int, io.Reader) {
+			return http.StatusPartialContent, strings.NewReader("streamed")
+		})
+		m.Get("/handler", func() http.Handler {
+			return http.RedirectHandler("/reader", http.StatusFound)
+		})
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/reader", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusPartialContent)
+		So(resp.Body.String(), ShouldEqual, "streamed")
+
+		resp = httptest.NewRecorder()
+		req, err = http.NewRequest("GET", "/handler", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusFound)
+		So(resp.Header().Get("Location"), ShouldEqual, "/reader")
+	})
 }
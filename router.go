@@ -15,9 +15,16 @@
 package macaron
 
 import (
+	"fmt"
+	"io"
 	"net/http"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"text/tabwriter"
 )
 
 var (
@@ -71,17 +78,49 @@ type group struct {
 	handlers []Handler
 }
 
+// route returns the pattern of the route prefixed with its host pattern and followed by its constraints.
+func (info *RouteInfo) route() string {
+	route := info.Host + info.Pattern
+	if len(info.Constraints) > 0 {
+		route += " (" + strings.Join(info.Constraints, ", ") + ")"
+	}
+	return route
+}
+
 // Router represents a Macaron router layer.
 type Router struct {
-	m        *Macaron
-	autoHead bool
-	routers  map[string]*Tree
+	m           *Macaron
+	autoHead    bool
+	autoOptions bool
+	routers     map[string]*Tree
 	*routeMap
 	namedRoutes map[string]*Leaf
-
-	groups              []group
-	notFound            http.HandlerFunc
-	internalServerError func(*Context, error)
+	routeInfos  []*RouteInfo
+
+	// hosts is the route tables of host patterns, host is the one routes are being added to.
+	hosts []*hostRouter
+	host  *hostRouter
+	// constraints is the stack of constraints of routes being added, constrained is
+	// the dispatcher of routes of every leaf.
+	constraints []Constraint
+	constrained map[*Leaf]*constrainedRoutes
+	// apiVersioning is the way of versioning used by APIVersion.
+	apiVersioning APIVersionOptions
+	// mountParent is the router the Macaron instance of the router is mounted to, and
+	// mountPattern is the prefix pattern it is mounted at.
+	mountParent  *Router
+	mountPattern string
+
+	groups                 []group
+	notFound               http.HandlerFunc
+	handleMethodNotAllowed bool
+	methodNotAllowed       http.HandlerFunc
+	internalServerError    func(*Context, error)
+
+	// Handlers of special cases are kept for validation of dependencies.
+	notFoundHandlers            []Handler
+	methodNotAllowedHandlers    []Handler
+	internalServerErrorHandlers []Handler
 
 	// handlerWrapper is used to wrap arbitrary function from Handler to inject.FastInvoker.
 	handlerWrapper func(Handler) Handler
@@ -92,6 +131,9 @@ func NewRouter() *Router {
 		routers:     make(map[string]*Tree),
 		routeMap:    NewRouteMap(),
 		namedRoutes: make(map[string]*Leaf),
+		constrained: make(map[*Leaf]*constrainedRoutes),
+
+		handleMethodNotAllowed: true,
 	}
 }
 
@@ -101,6 +143,20 @@ func (r *Router) SetAutoHead(v bool) {
 	r.autoHead = v
 }
 
+// SetAutoOptions sets the value who determines whether answer OPTIONS requests
+// automatically with an Allow header for patterns without an OPTIONS route.
+func (r *Router) SetAutoOptions(v bool) {
+	r.autoOptions = v
+}
+
+// SetHandleMethodNotAllowed sets the value who determines whether respond with
+// 405 Method Not Allowed and an Allow header when the path is registered but
+// not for the request method, instead of calling the NotFound handler. It is on by default,
+// set it to false to keep calling the NotFound handler.
+func (r *Router) SetHandleMethodNotAllowed(v bool) {
+	r.handleMethodNotAllowed = v
+}
+
 type Params map[string]string
 
 // Handle is a function that can be registered to a route to handle HTTP requests.
@@ -111,6 +167,7 @@ type Handle func(http.ResponseWriter, *http.Request, Params)
 type Route struct {
 	router *Router
 	leaf   *Leaf
+	infos  []*RouteInfo
 }
 
 // Name sets name of route.
@@ -121,16 +178,95 @@ func (r *Route) Name(name string) {
 		panic("route with given name already exists: " + name)
 	}
 	r.router.namedRoutes[name] = r.leaf
+	for _, info := range r.infos {
+		info.Name = name
+	}
+}
+
+// RouteInfo represents the information of a registered route.
+type RouteInfo struct {
+	// Method is the HTTP method of the route.
+	Method string
+	// Host is the host pattern the route is constrained by, it is empty for routes of any host.
+	Host string
+	// Pattern is the original pattern of the route, including patterns of its groups.
+	Pattern string
+	// Name is the name of the route, it is empty when the route is not named.
+	Name string
+	// Constraints is the descriptions of constraints the route is registered with, e.g. "version 2".
+	Constraints []string
+	// Groups is the chain of group patterns the route is registered within, from outermost.
+	Groups []string
+	// Handlers is the function names of group and route handlers, global middleware is excluded.
+	Handlers []string
+
+	handlers []Handler
+}
+
+// handlerName returns the function name of given handler.
+func handlerName(h Handler) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(handlerFunc(h)).Pointer())
+	if fn == nil {
+		return "???"
+	}
+	// Dots in the last element of the import path are escaped by the linker.
+	return strings.ReplaceAll(fn.Name(), "%2e", ".")
+}
+
+// routeInfo returns the information of route with given method and pattern,
+// a new one is created based on given template when it does not exist.
+func (r *Router) routeInfo(method, pattern string, tpl RouteInfo) *RouteInfo {
+	for _, info := range r.routeInfos {
+		if info.Method == method && info.Pattern == pattern && info.Host == tpl.Host &&
+			slices.Equal(info.Constraints, tpl.Constraints) {
+			return info
+		}
+	}
+
+	info := tpl
+	info.Method = method
+	info.Pattern = pattern
+	r.routeInfos = append(r.routeInfos, &info)
+	return &info
+}
+
+// Routes returns the information of all registered routes, sorted by host, pattern and method.
+func (r *Router) Routes() []RouteInfo {
+	routes := make([]RouteInfo, len(r.routeInfos))
+	for i := range r.routeInfos {
+		routes[i] = *r.routeInfos[i]
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// PrintRoutes writes a table of all registered routes sorted by host, pattern and method to given writer.
+// Patterns of routes constrained by host patterns are prefixed with the host pattern, and followed by
+// constraints of the route.
+func (r *Router) PrintRoutes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tNAME\tHANDLERS")
+	for _, route := range r.Routes() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", route.Method, route.route(), route.Name, strings.Join(route.Handlers, ", "))
+	}
+	return tw.Flush()
 }
 
 // handle adds new route to the router tree.
-func (r *Router) handle(method, pattern string, handle Handle) *Route {
+func (r *Router) handle(method, pattern string, handle Handle, tpl RouteInfo) *Route {
 	method = strings.ToUpper(method)
 
-	var leaf *Leaf
-	// Prevent duplicate routes.
-	if leaf = r.getLeaf(method, pattern); leaf != nil {
-		return &Route{r, leaf}
+	routers, routeMap := r.routers, r.routeMap
+	if r.host != nil {
+		routers, routeMap = r.host.routers, r.host.routeMap
 	}
 
 	// Validate HTTP methods.
@@ -149,26 +285,47 @@ func (r *Router) handle(method, pattern string, handle Handle) *Route {
 	}
 
 	// Add to router tree.
+	var leaf *Leaf
+	infos := make([]*RouteInfo, 0, len(methods))
 	for m := range methods {
-		if t, ok := r.routers[m]; ok {
-			leaf = t.Add(pattern, handle)
-		} else {
-			t := NewTree()
-			leaf = t.Add(pattern, handle)
-			r.routers[m] = t
+		// Routes of existing patterns are added to the dispatcher, duplicate routes are ignored.
+		if leaf = routeMap.getLeaf(m, pattern); leaf != nil {
+			r.constrained[leaf].add(r.constraints, handle)
+			infos = append(infos, r.routeInfo(m, pattern, tpl))
+			continue
+		}
+
+		t, ok := routers[m]
+		if !ok {
+			t = NewTree()
+			routers[m] = t
 		}
-		r.add(m, pattern, leaf)
+
+		cr := &constrainedRoutes{router: r}
+		cr.add(r.constraints, handle)
+		var err error
+		if leaf, err = t.addPattern(pattern, cr.handle); err != nil {
+			panic(m + " " + err.Error())
+		}
+		r.constrained[leaf] = cr
+		routeMap.add(m, pattern, leaf)
+		infos = append(infos, r.routeInfo(m, pattern, tpl))
 	}
-	return &Route{r, leaf}
+	return &Route{r, leaf, infos}
 }
 
 // Handle registers a new request handle with the given pattern, method and handlers.
 func (r *Router) Handle(method string, pattern string, handlers []Handler) *Route {
+	var groups []string
 	if len(r.groups) > 0 {
 		groupPattern := ""
 		h := make([]Handler, 0)
 		for _, g := range r.groups {
 			groupPattern += g.pattern
+			// Groups of hosts and constraints have no pattern.
+			if len(g.pattern) > 0 {
+				groups = append(groups, g.pattern)
+			}
 			h = append(h, g.handlers...)
 		}
 
@@ -176,16 +333,39 @@ func (r *Router) Handle(method string, pattern string, handlers []Handler) *Rout
 		h = append(h, handlers...)
 		handlers = h
 	}
+	rawHandlers := handlers
 	handlers = validateAndWrapHandlers(handlers, r.handlerWrapper)
 
+	paramTypes := getParamTypes(pattern)
+	info := RouteInfo{
+		Constraints: constraintStrings(r.constraints),
+		Groups:      groups,
+		Handlers:    make([]string, len(rawHandlers)),
+		handlers:    rawHandlers,
+	}
+	for i := range rawHandlers {
+		info.Handlers[i] = handlerName(rawHandlers[i])
+	}
+	if r.host != nil {
+		info.Host = r.host.pattern
+		for name, typ := range r.host.paramTypes {
+			if paramTypes == nil {
+				paramTypes = make(map[string]ParamType)
+			}
+			paramTypes[name] = typ
+		}
+	}
+
+	chain := r.m.newHandlerChain(handlers)
 	return r.handle(method, pattern, func(resp http.ResponseWriter, req *http.Request, params Params) {
 		c := r.m.createContext(resp, req)
 		c.params = params
-		c.handlers = make([]Handler, 0, len(r.m.handlers)+len(handlers))
-		c.handlers = append(c.handlers, r.m.handlers...)
-		c.handlers = append(c.handlers, handlers...)
+		c.paramTypes = paramTypes
+		c.pattern = pattern
+		c.handlers = chain.handlers
 		c.run()
-	})
+		r.m.releaseContext(c)
+	}, info)
 }
 
 func (r *Router) Group(pattern string, fn func(), h ...Handler) {
@@ -259,20 +439,38 @@ func (r *Router) Combo(pattern string, h ...Handler) *ComboRouter {
 // found. If it is not set, http.NotFound is used.
 // Be sure to set 404 response code in your handler.
 func (r *Router) NotFound(handlers ...Handler) {
-	handlers = validateAndWrapHandlers(handlers)
+	r.notFoundHandlers = handlers
+	chain := r.m.newHandlerChain(validateAndWrapHandlers(handlers))
 	r.notFound = func(rw http.ResponseWriter, req *http.Request) {
 		c := r.m.createContext(rw, req)
-		c.handlers = make([]Handler, 0, len(r.m.handlers)+len(handlers))
-		c.handlers = append(c.handlers, r.m.handlers...)
-		c.handlers = append(c.handlers, handlers...)
+		c.handlers = chain.handlers
+		c.run()
+		r.m.releaseContext(c)
+	}
+}
+
+// MethodNotAllowed configurates http.HandlerFunc which is called when the path is
+// matched by routes of other methods but not the request method, it only takes effect
+// after SetHandleMethodNotAllowed(true). The Allow header has been set before the call.
+// If it is not set, a plain 405 response is written.
+// Be sure to set 405 response code in your handler.
+func (r *Router) MethodNotAllowed(handlers ...Handler) {
+	r.methodNotAllowedHandlers = handlers
+	chain := r.m.newHandlerChain(validateAndWrapHandlers(handlers))
+	r.methodNotAllowed = func(rw http.ResponseWriter, req *http.Request) {
+		c := r.m.createContext(rw, req)
+		c.handlers = chain.handlers
 		c.run()
+		r.m.releaseContext(c)
 	}
 }
 
 // InternalServerError configurates handler which is called when route handler returns
-// error. If it is not set, default handler is used.
-// Be sure to set 500 response code in your handler.
+// error. If it is not set, default handler is used, which responds with the status of
+// HTTPError, or 500 for other errors. ProblemErrorHandler responds with problem details.
+// Be sure to set response code in your handler, e.g. by ErrorStatus(err).
 func (r *Router) InternalServerError(handlers ...Handler) {
+	r.internalServerErrorHandlers = handlers
 	handlers = validateAndWrapHandlers(handlers)
 	r.internalServerError = func(c *Context, err error) {
 		c.index = 0
@@ -287,35 +485,114 @@ func (r *Router) SetHandlerWrapper(f func(Handler) Handler) {
 	r.handlerWrapper = f
 }
 
+// serveRoute serves the request by the route matching its method and path in given route table,
+// and returns false if there is none. Params of the host are merged into params of the path.
+func serveRoute(routers map[string]*Tree, rm *routeMap, rw http.ResponseWriter, req *http.Request, hostParams Params) bool {
+	t, ok := routers[req.Method]
+	if !ok {
+		return false
+	}
+
+	// Fast match for static routes
+	leaf := rm.getLeaf(req.Method, req.URL.Path)
+	if leaf != nil {
+		leaf.handle(rw, req, hostParams)
+		return true
+	}
+
+	h, p, ok := t.Match(req.URL.EscapedPath())
+	if !ok {
+		return false
+	}
+	if splat, ok := p["*0"]; ok {
+		p["*"] = splat // Easy name.
+	}
+	for name, value := range hostParams {
+		setParam(&p, name, value)
+	}
+	h(rw, req, p)
+	return true
+}
+
 func (r *Router) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
-	if t, ok := r.routers[req.Method]; ok {
-		// Fast match for static routes
-		leaf := r.getLeaf(req.Method, req.URL.Path)
-		if leaf != nil {
-			leaf.handle(rw, req, nil)
-			return
+	if len(r.hosts) > 0 {
+		host := requestHost(req)
+		for _, hr := range r.hosts {
+			if params, ok := hr.match(host); ok && serveRoute(hr.routers, hr.routeMap, rw, req, params) {
+				return
+			}
 		}
+	}
+	if serveRoute(r.routers, r.routeMap, rw, req, nil) {
+		return
+	}
 
-		h, p, ok := t.Match(req.URL.EscapedPath())
-		if ok {
-			if splat, ok := p["*0"]; ok {
-				p["*"] = splat // Easy name.
+	if r.autoOptions || r.handleMethodNotAllowed {
+		if allowed := r.allowedMethods(req); len(allowed) > 0 {
+			if r.autoOptions {
+				allowed = append(allowed, "OPTIONS")
+			}
+			// Allow header is only set for responses to OPTIONS and 405.
+			if r.autoOptions && req.Method == "OPTIONS" {
+				rw.Header().Set("Allow", strings.Join(allowed, ", "))
+				rw.WriteHeader(http.StatusNoContent)
+				return
+			} else if r.handleMethodNotAllowed {
+				rw.Header().Set("Allow", strings.Join(allowed, ", "))
+				r.methodNotAllowed(rw, req)
+				return
 			}
-			h(rw, req, p)
-			return
 		}
 	}
 
 	r.notFound(rw, req)
 }
 
+// allowedMethods returns sorted methods that have a route matching the request host and path,
+// other than the request method. OPTIONS is left out when it is answered automatically.
+func (r *Router) allowedMethods(req *http.Request) []string {
+	methods := make(map[string]bool)
+	r.addAllowedMethods(methods, r.routers, r.routeMap, req)
+	if len(r.hosts) > 0 {
+		host := requestHost(req)
+		for _, hr := range r.hosts {
+			if _, ok := hr.match(host); ok {
+				r.addAllowedMethods(methods, hr.routers, hr.routeMap, req)
+			}
+		}
+	}
+
+	allowed := make([]string, 0, len(methods))
+	for method := range methods {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
+
+// addAllowedMethods adds methods that have a route matching the request path in given route table.
+func (r *Router) addAllowedMethods(methods map[string]bool, routers map[string]*Tree, rm *routeMap, req *http.Request) {
+	for method, t := range routers {
+		if method == req.Method || (method == "OPTIONS" && r.autoOptions) {
+			continue
+		}
+		if rm.getLeaf(method, req.URL.Path) != nil {
+			methods[method] = true
+			continue
+		}
+		if _, _, ok := t.Match(req.URL.EscapedPath()); ok {
+			methods[method] = true
+		}
+	}
+}
+
 // URLFor builds path part of URL by given pair values.
 func (r *Router) URLFor(name string, pairs ...string) string {
 	leaf, ok := r.namedRoutes[name]
 	if !ok {
 		panic("route with given name does not exists: " + name)
 	}
-	return leaf.URLPath(pairs...)
+	return fillURLPath(r.mountPrefix()+leaf.rawPath(), pairs)
 }
 
 // ComboRouter represents a combo router.
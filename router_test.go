@@ -15,10 +15,12 @@
 package macaron
 
 import (
+	"bytes"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -152,7 +154,7 @@ func test_Router_Handle(t *testing.T, isFast bool) {
 			req, err := http.NewRequest("HEAD", "/", nil)
 			So(err, ShouldBeNil)
 			m.ServeHTTP(resp, req)
-			So(resp.Code, ShouldEqual, 404)
+			So(resp.Code, ShouldEqual, 405)
 		})
 
 		Convey("With auto head", func() {
@@ -345,3 +347,156 @@ func Test_Router_splat(t *testing.T) {
 		So(resp.Body.String(), ShouldEqual, "hahaha")
 	})
 }
+
+func Test_Router_MethodNotAllowed(t *testing.T) {
+	Convey("Respond method not allowed", t, func() {
+		m := New()
+		m.Get("/user/:id", func() {})
+		m.Put("/user/:id", func() {})
+		m.Delete("/static", func() {})
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/user/1", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusMethodNotAllowed)
+		So(resp.Header().Get("Allow"), ShouldEqual, "GET, PUT")
+
+		resp = httptest.NewRecorder()
+		req, err = http.NewRequest("GET", "/static", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusMethodNotAllowed)
+		So(resp.Header().Get("Allow"), ShouldEqual, "DELETE")
+
+		Convey("Path is not registered at all", func() {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("POST", "/404", nil)
+			So(err, ShouldBeNil)
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusNotFound)
+			So(resp.Header().Get("Allow"), ShouldBeEmpty)
+		})
+
+		Convey("Custom method not allowed handler", func() {
+			m.MethodNotAllowed(func(ctx *Context) {
+				ctx.Resp.WriteHeader(http.StatusMethodNotAllowed)
+				_, _ = ctx.Resp.Write([]byte("Allowed: " + ctx.Resp.Header().Get("Allow")))
+			})
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("PATCH", "/user/1", nil)
+			So(err, ShouldBeNil)
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusMethodNotAllowed)
+			So(resp.Body.String(), ShouldEqual, "Allowed: GET, PUT")
+		})
+	})
+
+	Convey("Respond not found when disabled", t, func() {
+		m := New()
+		m.SetHandleMethodNotAllowed(false)
+		m.Get("/", func() {})
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusNotFound)
+		So(resp.Header().Get("Allow"), ShouldBeEmpty)
+	})
+}
+
+func Test_Router_AutoOptions(t *testing.T) {
+	Convey("Answer OPTIONS requests automatically", t, func() {
+		m := New()
+		m.SetAutoOptions(true)
+		m.SetHandleMethodNotAllowed(false)
+		m.Get("/user/:id", func() {})
+		m.Post("/user/:id", func() {})
+		m.Options("/custom", func() string { return "custom" })
+		m.Get("/custom", func() {})
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("OPTIONS", "/user/1", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusNoContent)
+		So(resp.Header().Get("Allow"), ShouldEqual, "GET, POST, OPTIONS")
+
+		resp = httptest.NewRecorder()
+		req, err = http.NewRequest("OPTIONS", "/custom", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Body.String(), ShouldEqual, "custom")
+
+		resp = httptest.NewRecorder()
+		req, err = http.NewRequest("OPTIONS", "/404", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusNotFound)
+
+		resp = httptest.NewRecorder()
+		req, err = http.NewRequest("DELETE", "/user/1", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusNotFound)
+		So(resp.Header().Get("Allow"), ShouldBeBlank)
+	})
+}
+
+func listUsers()   {}
+func requireAuth() {}
+
+func Test_Router_Routes(t *testing.T) {
+	Convey("List registered routes", t, func() {
+		m := New()
+		m.Get("/", func() {}).Name("home")
+		m.Group("/api", func() {
+			m.Group("/v1", func() {
+				m.Get("/users", listUsers).Name("users")
+				m.Post("/users", listUsers)
+			})
+		}, requireAuth)
+		m.Get("/", func() {}) // Duplicated route is ignored.
+
+		routes := m.Routes()
+		So(routes, ShouldHaveLength, 3)
+
+		So(routes[0].Method, ShouldEqual, "GET")
+		So(routes[0].Pattern, ShouldEqual, "/")
+		So(routes[0].Name, ShouldEqual, "home")
+		So(routes[0].Groups, ShouldBeEmpty)
+
+		So(routes[1].Method, ShouldEqual, "GET")
+		So(routes[1].Pattern, ShouldEqual, "/api/v1/users")
+		So(routes[1].Name, ShouldEqual, "users")
+		So(routes[1].Groups, ShouldResemble, []string{"/api", "/v1"})
+		So(routes[1].Handlers, ShouldResemble, []string{
+			"gopkg.in/macaron.v1.requireAuth",
+			"gopkg.in/macaron.v1.listUsers",
+		})
+
+		So(routes[2].Method, ShouldEqual, "POST")
+		So(routes[2].Name, ShouldBeEmpty)
+
+		Convey("Routes of any methods", func() {
+			m.Any("/any", func() {}).Name("any")
+			routes := m.Routes()
+			So(routes, ShouldHaveLength, 3+len(_HTTP_METHODS))
+			for _, route := range routes[1 : 1+len(_HTTP_METHODS)] {
+				So(route.Pattern, ShouldEqual, "/any")
+				So(route.Name, ShouldEqual, "any")
+			}
+		})
+
+		Convey("Print route table", func() {
+			var buf bytes.Buffer
+			So(m.PrintRoutes(&buf), ShouldBeNil)
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			So(lines, ShouldHaveLength, 4)
+			So(lines[0], ShouldStartWith, "METHOD")
+			So(lines[2], ShouldContainSubstring, "/api/v1/users")
+			So(lines[2], ShouldContainSubstring, "users")
+			So(lines[2], ShouldContainSubstring, "gopkg.in/macaron.v1.listUsers")
+		})
+	})
+}
@@ -0,0 +1,467 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/unknwon/com"
+)
+
+// DefaultShutdownTimeout is the default duration to wait for in-flight requests
+// to finish before the server is forcibly closed.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// StartHook is a function that is called once the server is about to accept
+// connections. Returning a non-nil error aborts the startup.
+type StartHook func() error
+
+// ShutdownHook is a function that is called after the server has stopped accepting
+// new connections. The given context is cancelled when the drain timeout expires.
+type ShutdownHook func(ctx context.Context) error
+
+// lifecycle holds the state of running servers and registered hooks.
+type lifecycle struct {
+	lock    sync.Mutex
+	servers []*server
+
+	onStart    []StartHook
+	onShutdown []ShutdownHook
+
+	shutdownTimeout time.Duration
+	shutdownSignals []os.Signal
+}
+
+// server is a running HTTP server, done is closed once it has been fully shut down.
+type server struct {
+	*http.Server
+	done chan struct{}
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		shutdownTimeout: DefaultShutdownTimeout,
+		shutdownSignals: []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+}
+
+// OnStart registers a hook which is called before the server starts accepting connections.
+// Hooks are invoked in the order that they are added.
+func (m *Macaron) OnStart(hook StartHook) {
+	m.lifecycle.lock.Lock()
+	defer m.lifecycle.lock.Unlock()
+
+	m.lifecycle.onStart = append(m.lifecycle.onStart, hook)
+}
+
+// OnShutdown registers a hook which is called once the server has been shut down.
+// Hooks are invoked in the order that they are added.
+func (m *Macaron) OnShutdown(hook ShutdownHook) {
+	m.lifecycle.lock.Lock()
+	defer m.lifecycle.lock.Unlock()
+
+	m.lifecycle.onShutdown = append(m.lifecycle.onShutdown, hook)
+}
+
+// SetShutdownTimeout sets the maximum duration to wait for in-flight requests to finish
+// when the server is shut down by a signal. Default is DefaultShutdownTimeout.
+func (m *Macaron) SetShutdownTimeout(d time.Duration) {
+	m.lifecycle.lock.Lock()
+	defer m.lifecycle.lock.Unlock()
+
+	m.lifecycle.shutdownTimeout = d
+}
+
+// SetShutdownSignals sets the signals that trigger a graceful shutdown.
+// Default are os.Interrupt and syscall.SIGTERM, no signal will be handled if
+// none is given.
+func (m *Macaron) SetShutdownSignals(sigs ...os.Signal) {
+	m.lifecycle.lock.Lock()
+	defer m.lifecycle.lock.Unlock()
+
+	m.lifecycle.shutdownSignals = sigs
+}
+
+// listenAddr returns the listen address composed by given arguments of Run.
+func listenAddr(args ...interface{}) string {
+	host, port := GetDefaultListenInfo()
+	if len(args) == 1 {
+		switch arg := args[0].(type) {
+		case string:
+			host = arg
+		case int:
+			port = arg
+		}
+	} else if len(args) >= 2 {
+		if arg, ok := args[0].(string); ok {
+			host = arg
+		}
+		if arg, ok := args[1].(int); ok {
+			port = arg
+		}
+	}
+	return host + ":" + com.ToStr(port)
+}
+
+// ListenAndServe works like Run but returns the error instead of exiting the process.
+// It blocks until the server is shut down, either by one of the shutdown signals or
+// by calling Shutdown, and returns nil in case of a graceful shutdown.
+func (m *Macaron) ListenAndServe(args ...interface{}) error {
+	return m.ListenAndServeAddrs(listenAddr(args...))
+}
+
+// ListenAndServeAddrs listens on all given addresses at once and serves requests on
+// them with the same server. Addresses prefixed by "unix:" are Unix domain socket paths.
+func (m *Macaron) ListenAndServeAddrs(addrs ...string) error {
+	listeners, err := listenAll(addrs)
+	if err != nil {
+		return err
+	}
+	return m.Serve(listeners...)
+}
+
+// ListenAndServeUnix listens on the Unix domain socket of given path and serves requests on it.
+// A stale socket file left by previous process is removed before listening.
+func (m *Macaron) ListenAndServeUnix(path string) error {
+	return m.ListenAndServeAddrs("unix:" + path)
+}
+
+// ListenAndServeTLS works like ListenAndServe but serves HTTPS requests with given certificate
+// and key files. The files are reloaded automatically when they are modified on disk.
+func (m *Macaron) ListenAndServeTLS(certFile, keyFile string, args ...interface{}) error {
+	ln, err := Listen(listenAddr(args...))
+	if err != nil {
+		return err
+	}
+
+	if err = m.ServeTLS(certFile, keyFile, ln); err != nil {
+		_ = ln.Close()
+	}
+	return err
+}
+
+// RunTLS runs the https server. Listening on os.GetEnv("PORT") or 4000 by default.
+// It exits the process when the server fails, use ListenAndServeTLS to handle the error instead.
+func (m *Macaron) RunTLS(certFile, keyFile string, args ...interface{}) {
+	if err := m.ListenAndServeTLS(certFile, keyFile, args...); err != nil {
+		m.getLogger().Fatalln(err)
+	}
+}
+
+// Serve accepts incoming connections on all given listeners until the server is shut down.
+// It is useful for serving on listeners created elsewhere, e.g. by SystemdListeners.
+func (m *Macaron) Serve(listeners ...net.Listener) error {
+	return m.serve(nil, listeners)
+}
+
+// ServeTLS works like Serve but serves HTTPS requests with given certificate and key files.
+// The files are reloaded automatically when they are modified on disk.
+func (m *Macaron) ServeTLS(certFile, keyFile string, listeners ...net.Listener) error {
+	cr, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	return m.serve(&tls.Config{GetCertificate: cr.GetCertificate}, listeners)
+}
+
+// serve accepts connections on given listeners until the server is shut down.
+// The TLS is enabled when the tlsConfig is not nil.
+func (m *Macaron) serve(tlsConfig *tls.Config, listeners []net.Listener) error {
+	if len(listeners) == 0 {
+		return errors.New("no listener to serve on")
+	}
+
+	srv := &server{&http.Server{Handler: m, TLSConfig: tlsConfig}, make(chan struct{})}
+
+	m.lifecycle.lock.Lock()
+	m.lifecycle.servers = append(m.lifecycle.servers, srv)
+	onStart := m.lifecycle.onStart
+	sigs := m.lifecycle.shutdownSignals
+	m.lifecycle.lock.Unlock()
+
+	m.reportDependencies()
+	for _, hook := range onStart {
+		if err := hook(); err != nil {
+			for _, ln := range listeners {
+				_ = ln.Close()
+			}
+			m.untrackServer(srv)
+			return err
+		}
+	}
+
+	errs := make(chan error, len(listeners))
+	for _, ln := range listeners {
+		m.getLogger().Printf("listening on %s (%s)\n", listenerName(ln), safeEnv())
+		go func(ln net.Listener) {
+			if tlsConfig != nil {
+				// Certificates are provided by the TLSConfig.GetCertificate.
+				errs <- srv.ServeTLS(ln, "", "")
+				return
+			}
+			errs <- srv.Serve(ln)
+		}(ln)
+	}
+
+	ctx := context.Background()
+	if len(sigs) > 0 {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, sigs...)
+		defer stop()
+	}
+
+	select {
+	case err := <-errs:
+		if errors.Is(err, http.ErrServerClosed) {
+			// Wait for the draining started by Shutdown to be finished.
+			<-srv.done
+			return nil
+		}
+		_ = srv.Close()
+		m.untrackServer(srv)
+		return err
+	case <-ctx.Done():
+	}
+
+	m.getLogger().Println("shutting down server...")
+	m.lifecycle.lock.Lock()
+	timeout := m.lifecycle.shutdownTimeout
+	m.lifecycle.lock.Unlock()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return m.Shutdown(shutdownCtx)
+}
+
+func (m *Macaron) untrackServer(srv *server) {
+	m.lifecycle.lock.Lock()
+	defer m.lifecycle.lock.Unlock()
+
+	for i := range m.lifecycle.servers {
+		if m.lifecycle.servers[i] == srv {
+			m.lifecycle.servers = append(m.lifecycle.servers[:i], m.lifecycle.servers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Shutdown gracefully shuts down all running servers without interrupting any
+// active connections, then calls the registered shutdown hooks. It waits until
+// all connections are idle or the given context is done, whichever comes first.
+func (m *Macaron) Shutdown(ctx context.Context) error {
+	m.lifecycle.lock.Lock()
+	servers := m.lifecycle.servers
+	m.lifecycle.servers = nil
+	onShutdown := m.lifecycle.onShutdown
+	m.lifecycle.lock.Unlock()
+
+	if len(servers) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, hook := range onShutdown {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, srv := range servers {
+		close(srv.done)
+	}
+	return errors.Join(errs...)
+}
+
+// Listen announces on the given address. Addresses prefixed by "unix:" are Unix domain
+// socket paths, and a stale socket file is removed before listening; others are TCP addresses.
+func Listen(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, "unix:") {
+		return net.Listen("tcp", addr)
+	}
+
+	path := strings.TrimPrefix(addr, "unix:")
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// listenAll announces on all given addresses, listeners already created are closed
+// if any of addresses fails.
+func listenAll(addrs []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		ln, err := Listen(addr)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+func listenerName(ln net.Listener) string {
+	addr := ln.Addr()
+	if addr.Network() == "unix" {
+		return "unix:" + addr.String()
+	}
+	return addr.String()
+}
+
+// _SD_LISTEN_FDS_START is the first file descriptor passed by systemd socket activation.
+const _SD_LISTEN_FDS_START = 3
+
+// SystemdListeners returns listeners passed by systemd socket activation through
+// LISTEN_PID and LISTEN_FDS environment variables. It returns no listener when
+// the process is not socket activated. The environment variables are unset so
+// that child processes do not inherit them.
+func SystemdListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	nfds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || nfds <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, nfds)
+	for i := 0; i < nfds; i++ {
+		name := "LISTEN_FD_" + strconv.Itoa(_SD_LISTEN_FDS_START+i)
+		if i < len(names) && len(names[i]) > 0 {
+			name = names[i]
+		}
+
+		f := os.NewFile(uintptr(_SD_LISTEN_FDS_START+i), name)
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, fmt.Errorf("systemd listener %q: %v", name, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+// certReloadInterval is the minimal interval between two checks of certificate files.
+var certReloadInterval = time.Second
+
+// certReloader provides the certificate of a TLS server, and reloads the
+// certificate once the certificate or key file has been modified.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	lock      sync.RWMutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	cr := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	modTime, err := cr.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err = cr.load(modTime); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// latestModTime returns the latest modification time of certificate and key files.
+func (cr *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{cr.certFile, cr.keyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return latest, err
+		}
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (cr *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return err
+	}
+
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+
+	cr.cert = &cert
+	cr.modTime = modTime
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate. The previous certificate keeps
+// being served when the new files cannot be loaded, e.g. in the middle of a rotation.
+func (cr *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.lock.Lock()
+	needCheck := time.Since(cr.checkedAt) >= certReloadInterval
+	if needCheck {
+		cr.checkedAt = time.Now()
+	}
+	lastModTime := cr.modTime
+	cr.lock.Unlock()
+
+	if needCheck {
+		if modTime, err := cr.latestModTime(); err == nil && !modTime.Equal(lastModTime) {
+			_ = cr.load(modTime)
+		}
+	}
+
+	cr.lock.RLock()
+	defer cr.lock.RUnlock()
+
+	return cr.cert, nil
+}
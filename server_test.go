@@ -0,0 +1,318 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// freePort returns a TCP port that is currently available on the loopback interface.
+func freePort() int {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func Test_listenAddr(t *testing.T) {
+	Convey("Compose listen address", t, func() {
+		t.Setenv("HOST", "")
+		t.Setenv("PORT", "")
+		So(listenAddr(), ShouldEqual, "0.0.0.0:4000")
+		So(listenAddr("127.0.0.1"), ShouldEqual, "127.0.0.1:4000")
+		So(listenAddr(4001), ShouldEqual, "0.0.0.0:4001")
+		So(listenAddr("127.0.0.1", 4002), ShouldEqual, "127.0.0.1:4002")
+	})
+}
+
+func Test_Macaron_Shutdown(t *testing.T) {
+	Convey("Gracefully shut down the server", t, func() {
+		m := NewWithLogger(&bytes.Buffer{})
+		m.SetShutdownSignals()
+
+		started := make(chan struct{})
+		m.OnStart(func() error {
+			close(started)
+			return nil
+		})
+		hookCalled := false
+		m.OnShutdown(func(ctx context.Context) error {
+			hookCalled = true
+			return nil
+		})
+
+		inFlight := make(chan struct{})
+		m.Get("/", func() string {
+			close(inFlight)
+			time.Sleep(200 * time.Millisecond)
+			return "drained"
+		})
+
+		port := freePort()
+		errs := make(chan error, 1)
+		go func() {
+			errs <- m.ListenAndServe("127.0.0.1", port)
+		}()
+		<-started
+
+		body := make(chan string, 1)
+		go func() {
+			resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/", port))
+			if err != nil {
+				body <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			p, _ := io.ReadAll(resp.Body)
+			body <- string(p)
+		}()
+		<-inFlight
+
+		So(m.Shutdown(context.Background()), ShouldBeNil)
+		So(<-body, ShouldEqual, "drained")
+		So(<-errs, ShouldBeNil)
+		So(hookCalled, ShouldBeTrue)
+
+		Convey("Shutdown without running server", func() {
+			So(m.Shutdown(context.Background()), ShouldBeNil)
+		})
+	})
+
+	Convey("Abort startup by start hook", t, func() {
+		m := NewWithLogger(&bytes.Buffer{})
+		m.OnStart(func() error {
+			return errors.New("not ready")
+		})
+		So(m.ListenAndServe("127.0.0.1", freePort()), ShouldNotBeNil)
+	})
+
+	Convey("Return listen error", t, func() {
+		m := NewWithLogger(&bytes.Buffer{})
+		So(m.ListenAndServe("256.0.0.1", 80), ShouldNotBeNil)
+	})
+}
+
+// writeTestCert writes a self-signed certificate with given serial number to the files.
+func writeTestCert(certFile, keyFile string, serial int64) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		panic(err)
+	}
+
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		panic(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		panic(err)
+	}
+}
+
+// startServer runs serve in background and waits until the server is started.
+func startServer(m *Macaron, serve func() error) <-chan error {
+	started := make(chan struct{})
+	m.OnStart(func() error {
+		close(started)
+		return nil
+	})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- serve()
+	}()
+	select {
+	case <-started:
+	case err := <-errs:
+		panic(err)
+	}
+	return errs
+}
+
+func Test_Macaron_ServeTLS(t *testing.T) {
+	Convey("Serve HTTPS with certificate reloading", t, func() {
+		dir := t.TempDir()
+		certFile := filepath.Join(dir, "cert.pem")
+		keyFile := filepath.Join(dir, "key.pem")
+		writeTestCert(certFile, keyFile, 1)
+
+		m := NewWithLogger(&bytes.Buffer{})
+		m.SetShutdownSignals()
+		m.Get("/", func() string { return "secure" })
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		errs := startServer(m, func() error {
+			return m.ServeTLS(certFile, keyFile, ln)
+		})
+
+		serialOf := func() int64 {
+			client := &http.Client{Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+				DisableKeepAlives: true,
+			}}
+			resp, err := client.Get("https://" + ln.Addr().String() + "/")
+			So(err, ShouldBeNil)
+			defer resp.Body.Close()
+			p, _ := io.ReadAll(resp.Body)
+			So(string(p), ShouldEqual, "secure")
+			return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+		}
+		So(serialOf(), ShouldEqual, 1)
+
+		defaultInterval := certReloadInterval
+		certReloadInterval = 0
+		defer func() { certReloadInterval = defaultInterval }()
+
+		writeTestCert(certFile, keyFile, 2)
+		later := time.Now().Add(time.Minute)
+		So(os.Chtimes(certFile, later, later), ShouldBeNil)
+		So(serialOf(), ShouldEqual, 2)
+
+		So(m.Shutdown(context.Background()), ShouldBeNil)
+		So(<-errs, ShouldBeNil)
+	})
+
+	Convey("Serve HTTPS with invalid certificate", t, func() {
+		m := NewWithLogger(&bytes.Buffer{})
+		So(m.ListenAndServeTLS("404.pem", "404.key", "127.0.0.1", freePort()), ShouldNotBeNil)
+	})
+}
+
+func Test_Macaron_ListenAndServeAddrs(t *testing.T) {
+	Convey("Serve on multiple addresses", t, func() {
+		m := NewWithLogger(&bytes.Buffer{})
+		m.SetShutdownSignals()
+		m.Get("/", func() string { return "multi" })
+
+		addrs := []string{
+			fmt.Sprintf("127.0.0.1:%d", freePort()),
+			fmt.Sprintf("127.0.0.1:%d", freePort()),
+		}
+		errs := startServer(m, func() error {
+			return m.ListenAndServeAddrs(addrs...)
+		})
+
+		for _, addr := range addrs {
+			resp, err := http.Get("http://" + addr + "/")
+			So(err, ShouldBeNil)
+			p, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			So(string(p), ShouldEqual, "multi")
+		}
+
+		So(m.Shutdown(context.Background()), ShouldBeNil)
+		So(<-errs, ShouldBeNil)
+	})
+
+	Convey("Fail to listen on one of addresses", t, func() {
+		m := NewWithLogger(&bytes.Buffer{})
+		So(m.ListenAndServeAddrs(fmt.Sprintf("127.0.0.1:%d", freePort()), "256.0.0.1:80"), ShouldNotBeNil)
+	})
+
+	Convey("Serve without listener", t, func() {
+		So(New().Serve(), ShouldNotBeNil)
+	})
+}
+
+func Test_Macaron_ListenAndServeUnix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix domain socket is not tested on Windows")
+	}
+
+	Convey("Serve on Unix domain socket", t, func() {
+		path := filepath.Join(t.TempDir(), "macaron.sock")
+
+		// Leave a stale socket file behind.
+		stale, err := net.Listen("unix", path)
+		So(err, ShouldBeNil)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		So(stale.Close(), ShouldBeNil)
+
+		m := NewWithLogger(&bytes.Buffer{})
+		m.SetShutdownSignals()
+		m.Get("/", func() string { return "unix" })
+		errs := startServer(m, func() error {
+			return m.ListenAndServeUnix(path)
+		})
+
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		}}
+		resp, err := client.Get("http://unix/")
+		So(err, ShouldBeNil)
+		p, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		So(string(p), ShouldEqual, "unix")
+
+		So(m.Shutdown(context.Background()), ShouldBeNil)
+		So(<-errs, ShouldBeNil)
+	})
+}
+
+func Test_SystemdListeners(t *testing.T) {
+	Convey("Get listeners without socket activation", t, func() {
+		t.Setenv("LISTEN_PID", "1")
+		t.Setenv("LISTEN_FDS", "1")
+		listeners, err := SystemdListeners()
+		So(err, ShouldBeNil)
+		So(listeners, ShouldBeEmpty)
+	})
+
+	Convey("Get listeners without passed file descriptors", t, func() {
+		t.Setenv("LISTEN_PID", fmt.Sprint(os.Getpid()))
+		t.Setenv("LISTEN_FDS", "0")
+		listeners, err := SystemdListeners()
+		So(err, ShouldBeNil)
+		So(listeners, ShouldBeEmpty)
+	})
+}
@@ -16,14 +16,20 @@
 package macaron
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
-	"fmt"
+	"encoding/hex"
+	"io"
+	"io/fs"
 	"log"
+	"mime"
 	"net/http"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
 // StaticOptions is a struct for specifying configuration options for the macaron.Static middleware.
@@ -37,11 +43,65 @@ type StaticOptions struct {
 	// Expires defines which user-defined function to use for producing a HTTP Expires Header
 	// https://developers.google.com/speed/docs/insights/LeverageBrowserCaching
 	Expires func() string
-	// ETag defines if we should add an ETag header
+	// ETag defines if we should add an ETag header, which is generated from the hash of file content.
 	// https://developers.google.com/web/fundamentals/performance/optimizing-content-efficiency/http-caching#validating-cached-responses-with-etags
 	ETag bool
 	// FileSystem is the interface for supporting any implmentation of file system.
 	FileSystem http.FileSystem
+	// FS is the file system to serve files from, e.g. embed.FS. The directory given to
+	// Static or Statics is the sub-tree of it to serve, use "." to serve the whole FS.
+	// It takes precedence over FileSystem.
+	FS fs.FS
+	// ModTime is used as the modification time of files that have none, e.g. embedded files.
+	// No Last-Modified header is sent for such files if it is zero.
+	ModTime time.Time
+	// Precompressed enables serving precompressed sibling files, i.e. "<name>.br", "<name>.zst"
+	// and "<name>.gz", instead of the requested file when the client accepts the content coding.
+	Precompressed bool
+
+	etags *etagCache
+}
+
+// etagEntry is a cached ETag of a file with the size and modification time it was generated with.
+type etagEntry struct {
+	size    int64
+	modTime time.Time
+	tag     string
+}
+
+// etagCache caches ETags of files by path to avoid hashing unchanged files on every request.
+type etagCache struct {
+	entries sync.Map
+}
+
+// get returns the ETag of given file, which is regenerated when size or modification time changes.
+func (c *etagCache) get(name string, fi fs.FileInfo, f io.ReadSeeker) (string, error) {
+	if v, ok := c.entries.Load(name); ok {
+		e := v.(etagEntry)
+		if e.size == fi.Size() && e.modTime.Equal(fi.ModTime()) {
+			return e.tag, nil
+		}
+	}
+
+	tag, err := contentETag(f)
+	if err != nil {
+		return "", err
+	}
+	c.entries.Store(name, etagEntry{fi.Size(), fi.ModTime(), tag})
+	return tag, nil
+}
+
+// contentETag generates a strong ETag from the SHA-256 hash of content,
+// and rewinds the content for serving afterwards.
+func contentETag(f io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`, nil
 }
 
 // FIXME: to be deleted.
@@ -105,9 +165,16 @@ func prepareStaticOption(dir string, opt StaticOptions) StaticOptions {
 		// Remove any trailing '/'
 		opt.Prefix = strings.TrimRight(opt.Prefix, "/")
 	}
-	if opt.FileSystem == nil {
+	if opt.FS != nil {
+		fsys, err := fs.Sub(opt.FS, path.Clean(filepath.ToSlash(dir)))
+		if err != nil {
+			panic("static: " + err.Error())
+		}
+		opt.FileSystem = http.FS(fsys)
+	} else if opt.FileSystem == nil {
 		opt.FileSystem = newStaticFileSystem(dir)
 	}
+	opt.etags = &etagCache{}
 	return opt
 }
 
@@ -174,8 +241,28 @@ func staticHandler(ctx *Context, log *log.Logger, opt StaticOptions) bool {
 		}
 	}
 
+	// The key of ETag is the name of file actually served.
+	name := file
+	if opt.Precompressed {
+		addVary(ctx.Resp.Header(), _ACCEPT_ENCODING)
+		cf, cfi, coding := openPrecompressed(opt.FileSystem, file, ctx.Req.Header.Get(_ACCEPT_ENCODING))
+		if cf != nil {
+			defer cf.Close()
+
+			contentType, err := detectContentType(file, f)
+			if err != nil {
+				http.Error(ctx.Resp, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return true
+			}
+			ctx.Resp.Header().Set(_CONTENT_TYPE, contentType)
+			ctx.Resp.Header().Set(_CONTENT_ENCODING, coding)
+			name = file + precompressedExts[coding]
+			f, fi = cf, cfi
+		}
+	}
+
 	if !opt.SkipLogging {
-		log.Println("[Static] Serving " + file)
+		log.Println("[Static] Serving " + name)
 	}
 
 	// Add an Expires header to the static content
@@ -184,7 +271,11 @@ func staticHandler(ctx *Context, log *log.Logger, opt StaticOptions) bool {
 	}
 
 	if opt.ETag {
-		tag := `"` + GenerateETag(fmt.Sprintf("%d", fi.Size()), fi.Name(), fi.ModTime().UTC().Format(http.TimeFormat)) + `"`
+		tag, err := opt.etags.get(name, fi, f)
+		if err != nil {
+			http.Error(ctx.Resp, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return true
+		}
 		ctx.Resp.Header().Set("ETag", tag)
 		if ctx.Req.Header.Get("If-None-Match") == tag {
 			ctx.Resp.WriteHeader(http.StatusNotModified)
@@ -192,11 +283,79 @@ func staticHandler(ctx *Context, log *log.Logger, opt StaticOptions) bool {
 		}
 	}
 
-	http.ServeContent(ctx.Resp, ctx.Req.Request, file, fi.ModTime(), f)
+	modTime := fi.ModTime()
+	if modTime.IsZero() {
+		modTime = opt.ModTime
+	}
+	http.ServeContent(ctx.Resp, ctx.Req.Request, file, modTime, f)
 	return true
 }
 
-// GenerateETag generates an ETag based on size, filename and file modification time
+// precompressedExts maps content codings to file extensions of precompressed files in the order of preference.
+var precompressedExts = map[string]string{
+	"br":   ".br",
+	"zstd": ".zst",
+	"gzip": ".gz",
+}
+
+var precompressedCodings = []string{"br", "zstd", "gzip"}
+
+// openPrecompressed opens the best precompressed file of given name acceptable by the Accept-Encoding header,
+// it returns nil file if none exists or is acceptable.
+func openPrecompressed(fsys http.FileSystem, name, acceptEncoding string) (http.File, os.FileInfo, string) {
+	if len(acceptEncoding) == 0 {
+		return nil, nil, ""
+	}
+
+	files := make(map[string]http.File, len(precompressedCodings))
+	infos := make(map[string]os.FileInfo, len(precompressedCodings))
+	offers := make([]string, 0, len(precompressedCodings))
+	for _, coding := range precompressedCodings {
+		f, err := fsys.Open(name + precompressedExts[coding])
+		if err != nil {
+			continue
+		}
+		fi, err := f.Stat()
+		if err != nil || fi.IsDir() {
+			f.Close()
+			continue
+		}
+		files[coding], infos[coding] = f, fi
+		offers = append(offers, coding)
+	}
+
+	coding := NegotiateEncoding(acceptEncoding, offers)
+	for c, f := range files {
+		if c != coding {
+			f.Close()
+		}
+	}
+	if len(coding) == 0 {
+		return nil, nil, ""
+	}
+	return files[coding], infos[coding], coding
+}
+
+// detectContentType returns the content type of the file by its extension, or by sniffing its content
+// if the extension is unknown. The content is rewound afterwards.
+func detectContentType(name string, f io.ReadSeeker) (string, error) {
+	if contentType := mime.TypeByExtension(path.Ext(name)); len(contentType) > 0 {
+		return contentType, nil
+	}
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// GenerateETag generates an ETag based on size, filename and file modification time.
+// Static no longer uses it but generates ETags from file content.
 func GenerateETag(fileSize, fileName, modTime string) string {
 	etag := fileSize + fileName + modTime
 	return base64.StdEncoding.EncodeToString([]byte(etag))
@@ -17,13 +17,16 @@ package macaron
 
 import (
 	"bytes"
-	"fmt"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -197,7 +200,8 @@ func Test_Static_Options(t *testing.T) {
 		req, err := http.NewRequest("GET", "http://localhost:4000/macaron.go", nil)
 		So(err, ShouldBeNil)
 		m.ServeHTTP(resp, req)
-		tag := GenerateETag(fmt.Sprintf("%d", resp.Body.Len()), "macaron.go", resp.Header().Get("last-modified"))
+		sum := sha256.Sum256(resp.Body.Bytes())
+		tag := hex.EncodeToString(sum[:16])
 
 		So(resp.Header().Get("ETag"), ShouldEqual, `"`+tag+`"`)
 	})
@@ -211,7 +215,8 @@ func Test_Static_Options(t *testing.T) {
 		req, err := http.NewRequest("GET", "http://localhost:4000/macaron.go", nil)
 		So(err, ShouldBeNil)
 		m.ServeHTTP(resp, req)
-		tag := GenerateETag(fmt.Sprintf("%d", resp.Body.Len()), "macaron.go", resp.Header().Get("last-modified"))
+		sum := sha256.Sum256(resp.Body.Bytes())
+		tag := hex.EncodeToString(sum[:16])
 
 		// Second request with ETag in If-None-Match
 		resp = httptest.NewRecorder()
@@ -223,6 +228,139 @@ func Test_Static_Options(t *testing.T) {
 	})
 }
 
+func Test_Static_FS(t *testing.T) {
+	Convey("Serve static files from embedded file system", t, func() {
+		var buf bytes.Buffer
+		m := NewWithLogger(&buf)
+		m.Use(Static("fixtures/basic", StaticOptions{
+			Prefix: "public",
+			FS:     fixturesFS,
+			ETag:   true,
+		}))
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "http://localhost:4000/public/admin/index.tmpl", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+
+		So(resp.Code, ShouldEqual, http.StatusOK)
+		So(resp.Body.String(), ShouldEqual, "<h1>Admin {{.}}</h1>")
+		So(resp.Header().Get("Last-Modified"), ShouldBeBlank)
+		sum := sha256.Sum256([]byte("<h1>Admin {{.}}</h1>"))
+		tag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		So(resp.Header().Get("ETag"), ShouldEqual, tag)
+
+		resp = httptest.NewRecorder()
+		req.Header.Set("If-None-Match", tag)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusNotModified)
+
+		resp = httptest.NewRecorder()
+		req, err = http.NewRequest("GET", "http://localhost:4000/public/404.tmpl", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("Serve embedded files with modification time", t, func() {
+		modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		m := New()
+		m.Use(Statics(StaticOptions{
+			SkipLogging: true,
+			FS:          fixturesFS,
+			ModTime:     modTime,
+		}, "fixtures/basic", "fixtures/basic2"))
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "http://localhost:4000/hello2.tmpl", nil)
+		So(err, ShouldBeNil)
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Last-Modified"), ShouldEqual, modTime.Format(http.TimeFormat))
+
+		resp = httptest.NewRecorder()
+		req.Header.Set("If-Modified-Since", modTime.Format(http.TimeFormat))
+		m.ServeHTTP(resp, req)
+		So(resp.Code, ShouldEqual, http.StatusNotModified)
+	})
+
+	Convey("Serve invalid sub-tree of file system", t, func() {
+		defer func() {
+			So(recover(), ShouldNotBeNil)
+		}()
+		Static("../", StaticOptions{FS: fixturesFS})
+	})
+}
+
+func Test_Static_Precompressed(t *testing.T) {
+	Convey("Serve precompressed static files", t, func() {
+		modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		m := New()
+		m.Use(Static(".", StaticOptions{
+			SkipLogging: true,
+			FS: fstest.MapFS{
+				"app.js":    {Data: []byte("console.log('raw')"), ModTime: modTime},
+				"app.js.gz": {Data: []byte("gzip"), ModTime: modTime},
+				"app.js.br": {Data: []byte("brotli"), ModTime: modTime},
+				"data":      {Data: []byte("<html>"), ModTime: modTime},
+				"data.zst":  {Data: []byte("zstd"), ModTime: modTime},
+			},
+			ETag:          true,
+			Precompressed: true,
+		}))
+		request := func(url, acceptEncoding string, headers ...string) *httptest.ResponseRecorder {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", url, nil)
+			So(err, ShouldBeNil)
+			req.Header.Set(_ACCEPT_ENCODING, acceptEncoding)
+			for i := 0; i+1 < len(headers); i += 2 {
+				req.Header.Set(headers[i], headers[i+1])
+			}
+			m.ServeHTTP(resp, req)
+			return resp
+		}
+
+		resp := request("/app.js", "gzip, br")
+		So(resp.Code, ShouldEqual, http.StatusOK)
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldEqual, "br")
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldStartWith, "text/javascript")
+		So(resp.Header().Get("Vary"), ShouldEqual, _ACCEPT_ENCODING)
+		So(resp.Body.String(), ShouldEqual, "brotli")
+		brTag := resp.Header().Get("ETag")
+
+		resp = request("/app.js", "gzip")
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldEqual, "gzip")
+		So(resp.Body.String(), ShouldEqual, "gzip")
+		So(resp.Header().Get("ETag"), ShouldNotEqual, brTag)
+
+		resp = request("/app.js", "")
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldBeBlank)
+		So(resp.Body.String(), ShouldEqual, "console.log('raw')")
+
+		resp = request("/app.js", "zstd")
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldBeBlank)
+		So(resp.Body.String(), ShouldEqual, "console.log('raw')")
+
+		resp = request("/data", "zstd")
+		So(resp.Header().Get(_CONTENT_ENCODING), ShouldEqual, "zstd")
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldStartWith, "text/html")
+		So(resp.Body.String(), ShouldEqual, "zstd")
+
+		Convey("Keep range and conditional requests", func() {
+			resp := request("/app.js", "br", "Range", "bytes=1-3")
+			So(resp.Code, ShouldEqual, http.StatusPartialContent)
+			So(resp.Header().Get(_CONTENT_ENCODING), ShouldEqual, "br")
+			So(resp.Body.String(), ShouldEqual, "rot")
+
+			resp = request("/app.js", "br", "If-None-Match", brTag)
+			So(resp.Code, ShouldEqual, http.StatusNotModified)
+
+			resp = request("/app.js", "br", "If-Modified-Since", modTime.Format(http.TimeFormat))
+			So(resp.Code, ShouldEqual, http.StatusNotModified)
+		})
+	})
+}
+
 func Test_Static_Redirect(t *testing.T) {
 	Convey("Serve static files with prefix without redirect", t, func() {
 		m := New()
@@ -15,9 +15,13 @@
 package macaron
 
 import (
+	"fmt"
 	gourl "net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/unknwon/com"
 )
@@ -41,41 +45,131 @@ type Leaf struct {
 	rawPattern string // Contains wildcard instead of regexp
 	wildcards  []string
 	reg        *regexp.Regexp
+	class      func(byte) bool
+	// converters is the converters of params declared with param types.
+	converters map[string]func(string) (interface{}, error)
 	optional   bool
+	// route is the pattern of the route that added the leaf.
+	route string
 
 	handle Handle
 }
 
 var wildcardPattern = regexp.MustCompile(`:[a-zA-Z0-9]+`)
 
-func isSpecialRegexp(pattern, regStr string, pos []int) bool {
-	return len(pattern) >= pos[1]+len(regStr) && pattern[pos[1]:pos[1]+len(regStr)] == regStr
+// ParamType is a named type of route params, which is declared after the name of a wildcard
+// in route patterns, e.g. "/:id:uuid".
+type ParamType struct {
+	// Pattern is the regexp matching values of the type, it must not contain capturing groups.
+	Pattern string
+	// Convert converts matched values to typed values returned by Context.ParamValue, the route
+	// is not matched if it fails. Values are returned as strings if it is nil.
+	Convert func(value string) (interface{}, error)
+
+	// unanchored is true for int and string, which match anywhere in the segment for compatibility.
+	unanchored bool
 }
 
-// getNextWildcard tries to find next wildcard and update pattern with corresponding regexp.
-func getNextWildcard(pattern string) (wildcard, _ string) {
-	pos := wildcardPattern.FindStringIndex(pattern)
+var paramTypes = struct {
+	lock  sync.RWMutex
+	types map[string]ParamType
+}{types: map[string]ParamType{
+	"int":    {Pattern: `[0-9]+`, Convert: func(v string) (interface{}, error) { return strconv.Atoi(v) }, unanchored: true},
+	"string": {Pattern: `[\w]+`, unanchored: true},
+	"uuid":   {Pattern: `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`},
+	"slug":   {Pattern: `[a-z0-9]+(?:-[a-z0-9]+)*`},
+	"hex":    {Pattern: `[0-9a-fA-F]+`},
+	"date":   {Pattern: `[0-9]{4}-[0-9]{2}-[0-9]{2}`, Convert: func(v string) (interface{}, error) { return time.Parse("2006-01-02", v) }},
+}}
+
+var paramTypeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+
+// AddParamType registers a custom type of route params with given name, which can be used
+// in patterns of routes registered afterwards. Built-in types are int, string, uuid, slug,
+// hex and date, values of int and date are converted to int and time.Time. Segments with
+// params of types other than int and string must be matched entirely.
+func AddParamType(name string, typ ParamType) {
+	if !paramTypeNamePattern.MatchString(name) {
+		panic("invalid param type name: " + name)
+	} else if reg := regexp.MustCompile(typ.Pattern); reg.NumSubexp() > 0 {
+		panic("pattern of param type " + name + " must not contain capturing groups")
+	}
+
+	paramTypes.lock.Lock()
+	defer paramTypes.lock.Unlock()
+
+	paramTypes.types[name] = typ
+}
+
+func getParamType(name string) (ParamType, bool) {
+	paramTypes.lock.RLock()
+	defer paramTypes.lock.RUnlock()
+
+	typ, ok := paramTypes.types[name]
+	return typ, ok
+}
+
+// paramTypeAt returns the name and the param type declared at beginning of the pattern, e.g. ":uuid".
+func paramTypeAt(pattern string) (string, ParamType, bool) {
+	if len(pattern) < 2 || pattern[0] != ':' {
+		return "", ParamType{}, false
+	}
+	name := wildcardPattern.FindString(pattern)
+	if len(name) == 0 || !strings.HasPrefix(pattern, name) {
+		return "", ParamType{}, false
+	}
+	typ, ok := getParamType(name[1:])
+	return name, typ, ok
+}
+
+// closingParen returns the index of the parenthesis closing the group at beginning of the pattern,
+// or -1 if there is none.
+func closingParen(pattern string) int {
+	depth := 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// getNextWildcard tries to find next wildcard after the offset and update pattern with corresponding
+// regexp. It returns the offset to find the wildcard after the updated one.
+func getNextWildcard(pattern string, offset int) (wildcard, _ string, _ int) {
+	pos := wildcardPattern.FindStringIndex(pattern[offset:])
 	if pos == nil {
-		return "", pattern
+		return "", pattern, len(pattern)
 	}
+	pos[0] += offset
+	pos[1] += offset
 	wildcard = pattern[pos[0]:pos[1]]
 
 	// Reach last character or no regexp is given.
 	if len(pattern) == pos[1] {
-		return wildcard, strings.Replace(pattern, wildcard, `(.+)`, 1)
+		return wildcard, pattern[:pos[0]] + `(.+)`, len(pattern) - len(wildcard) + 4
 	} else if pattern[pos[1]] != '(' {
-		switch {
-		case isSpecialRegexp(pattern, ":int", pos):
-			pattern = strings.Replace(pattern, ":int", "([0-9]+)", 1)
-		case isSpecialRegexp(pattern, ":string", pos):
-			pattern = strings.Replace(pattern, ":string", "([\\w]+)", 1)
-		default:
-			return wildcard, strings.Replace(pattern, wildcard, `(.+)`, 1)
+		name, typ, ok := paramTypeAt(pattern[pos[1]:])
+		if !ok {
+			return wildcard, pattern[:pos[0]] + `(.+)` + pattern[pos[1]:], pos[0] + 4
 		}
+		pattern = pattern[:pos[1]] + "(" + typ.Pattern + ")" + pattern[pos[1]+len(name):]
 	}
 
-	// Cut out placeholder directly.
-	return wildcard, pattern[:pos[0]] + pattern[pos[1]:]
+	// Cut out placeholder directly, and skip the regexp.
+	pattern = pattern[:pos[0]] + pattern[pos[1]:]
+	end := closingParen(pattern[pos[0]:])
+	if end == -1 {
+		return wildcard, pattern, len(pattern)
+	}
+	return wildcard, pattern, pos[0] + end + 1
 }
 
 func getWildcards(pattern string) (string, []string) {
@@ -83,8 +177,8 @@ func getWildcards(pattern string) (string, []string) {
 
 	// Keep getting next wildcard until nothing is left.
 	var wildcard string
-	for {
-		wildcard, pattern = getNextWildcard(pattern)
+	for offset := 0; offset < len(pattern); {
+		wildcard, pattern, offset = getNextWildcard(pattern, offset)
 		if len(wildcard) > 0 {
 			wildcards = append(wildcards, wildcard)
 		} else {
@@ -95,10 +189,59 @@ func getWildcards(pattern string) (string, []string) {
 	return pattern, wildcards
 }
 
+// getParamTypes returns param types declared in the pattern by names of wildcards.
+func getParamTypes(pattern string) map[string]ParamType {
+	var types map[string]ParamType
+	for _, pos := range wildcardPattern.FindAllStringIndex(pattern, -1) {
+		if name, typ, ok := paramTypeAt(pattern[pos[1]:]); ok && len(name) > 0 {
+			if types == nil {
+				types = make(map[string]ParamType)
+			}
+			types[pattern[pos[0]:pos[1]]] = typ
+		}
+	}
+	return types
+}
+
+// paramConverters returns converters of params declared with param types in the pattern,
+// and whether the pattern must match the segment entirely.
+func paramConverters(pattern string) (converters map[string]func(string) (interface{}, error), anchored bool) {
+	for name, typ := range getParamTypes(pattern) {
+		if !typ.unanchored {
+			anchored = true
+		}
+		if typ.Convert == nil {
+			continue
+		}
+		if converters == nil {
+			converters = make(map[string]func(string) (interface{}, error))
+		}
+		converters[name] = typ.Convert
+	}
+	return converters, anchored
+}
+
+// convertParams returns false if any param fails to be converted by its converter.
+func convertParams(converters map[string]func(string) (interface{}, error), params Params) bool {
+	for name, convert := range converters {
+		if _, err := convert(params[name]); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+var paramTypeSuffixPattern = regexp.MustCompile(`:[a-zA-Z0-9]+:[a-zA-Z0-9]+`)
+
 // getRawPattern removes all regexp but keeps wildcards for building URL path.
 func getRawPattern(rawPattern string) string {
-	rawPattern = strings.ReplaceAll(rawPattern, ":int", "")
-	rawPattern = strings.ReplaceAll(rawPattern, ":string", "")
+	rawPattern = paramTypeSuffixPattern.ReplaceAllStringFunc(rawPattern, func(s string) string {
+		i := strings.LastIndexByte(s, ':')
+		if _, ok := getParamType(s[i+1:]); ok {
+			return s[:i]
+		}
+		return s
+	})
 
 	for {
 		startIdx := strings.Index(rawPattern, "(")
@@ -117,6 +260,7 @@ func getRawPattern(rawPattern string) string {
 func checkPattern(pattern string) (typ patternType, rawPattern string, wildcards []string, reg *regexp.Regexp) {
 	pattern = strings.TrimLeft(pattern, "?")
 	rawPattern = getRawPattern(pattern)
+	_, anchored := paramConverters(pattern)
 
 	if pattern == "*" {
 		typ = _PATTERN_MATCH_ALL
@@ -127,6 +271,8 @@ func checkPattern(pattern string) (typ patternType, rawPattern string, wildcards
 		pattern, wildcards = getWildcards(pattern)
 		if pattern == "(.+)" {
 			typ = _PATTERN_HOLDER
+		} else if anchored {
+			reg = regexp.MustCompile("^" + pattern + "$")
 		} else {
 			reg = regexp.MustCompile(pattern)
 		}
@@ -134,24 +280,121 @@ func checkPattern(pattern string) (typ patternType, rawPattern string, wildcards
 	return typ, rawPattern, wildcards, reg
 }
 
+// charClassOf returns the matcher of the character class when the regexp only captures a run
+// of the class, e.g. ([0-9]+) of ":id:int", so that it can be matched without the regexp.
+func charClassOf(reg *regexp.Regexp) func(byte) bool {
+	if reg == nil {
+		return nil
+	}
+	switch reg.String() {
+	case "([0-9]+)":
+		return isDigit
+	case `([\w]+)`:
+		return isWordChar
+	}
+	return nil
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isWordChar(c byte) bool {
+	return isDigit(c) || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || c == '_'
+}
+
+// setParam sets the value of param, the params are allocated on demand.
+func setParam(params *Params, name, value string) {
+	if *params == nil {
+		*params = make(Params, 2)
+	}
+	(*params)[name] = value
+}
+
+// matchRegexp matches the segment by the regexp and sets values of wildcards to params.
+// Like regexp.FindStringSubmatch, the leftmost match within the segment is used.
+func matchRegexp(reg *regexp.Regexp, class func(byte) bool, wildcards []string, segment string, params *Params) bool {
+	if class != nil {
+		i := 0
+		for i < len(segment) && !class(segment[i]) {
+			i++
+		}
+		if i == len(segment) {
+			return false
+		}
+		j := i + 1
+		for j < len(segment) && class(segment[j]) {
+			j++
+		}
+		setParam(params, wildcards[0], segment[i:j])
+		return true
+	}
+
+	results := reg.FindStringSubmatch(segment)
+	// Number of results and wildcards should be exact same.
+	if len(results)-1 != len(wildcards) {
+		return false
+	}
+	for i := range wildcards {
+		setParam(params, wildcards[i], results[i+1])
+	}
+	return true
+}
+
+// setPathExt sets the path and extension of url to params.
+func setPathExt(url string, params *Params) {
+	if i := strings.LastIndex(url, "."); i > -1 {
+		setParam(params, ":path", url[:i])
+		setParam(params, ":ext", url[i+1:])
+	} else {
+		setParam(params, ":path", url)
+	}
+}
+
+// setMatchAll sets url matched by the "*" at given glob level to params.
+func setMatchAll(globLevel int, url string, params *Params) {
+	setParam(params, "*", url)
+	setParam(params, "*"+strconv.Itoa(globLevel), url)
+}
+
+// unescapePath unescapes the part of URL path, it is returned as is when nothing is escaped.
+func unescapePath(s string) (string, bool) {
+	if strings.IndexByte(s, '%') == -1 {
+		return s, true
+	}
+	s, err := gourl.PathUnescape(s)
+	return s, err == nil
+}
+
 func NewLeaf(parent *Tree, pattern string, handle Handle) *Leaf {
 	typ, rawPattern, wildcards, reg := checkPattern(pattern)
 	optional := len(pattern) > 0 && pattern[0] == '?'
-	return &Leaf{parent, typ, pattern, rawPattern, wildcards, reg, optional, handle}
+	converters, _ := paramConverters(pattern)
+	return &Leaf{parent, typ, pattern, rawPattern, wildcards, reg, charClassOf(reg), converters, optional, "", handle}
 }
 
 // URLPath build path part of URL by given pair values.
 func (l *Leaf) URLPath(pairs ...string) string {
-	if len(pairs)%2 != 0 {
-		panic("number of pairs does not match")
-	}
+	return fillURLPath(l.rawPath(), pairs)
+}
 
+// rawPath returns the raw pattern of the route of the leaf, which contains wildcards instead of regexp.
+func (l *Leaf) rawPath() string {
 	urlPath := l.rawPattern
 	parent := l.parent
 	for parent != nil {
 		urlPath = parent.rawPattern + "/" + urlPath
 		parent = parent.parent
 	}
+	return urlPath
+}
+
+// fillURLPath replaces wildcards of given raw pattern with given pair values.
+func fillURLPath(urlPath string, pairs []string) string {
+	if len(pairs)%2 != 0 {
+		panic("number of pairs does not match")
+	}
+
 	for i := 0; i < len(pairs); i += 2 {
 		if len(pairs[i]) == 0 {
 			panic("pair value cannot be empty: " + com.ToStr(i))
@@ -163,7 +406,9 @@ func (l *Leaf) URLPath(pairs ...string) string {
 	return urlPath
 }
 
-// Tree represents a router tree in Macaron.
+// Tree represents a router tree in Macaron. It is a radix tree of path segments, where a chain
+// of static segments without branches is compressed into a single subtree. Static subtrees and
+// leaves are looked up by the segment, dynamic ones are tried in order of their pattern types.
 type Tree struct {
 	parent *Tree
 
@@ -172,36 +417,112 @@ type Tree struct {
 	rawPattern string
 	wildcards  []string
 	reg        *regexp.Regexp
+	class      func(byte) bool
+	converters map[string]func(string) (interface{}, error)
+	// segments is the compressed static segments of a static subtree.
+	segments []string
+
+	statics      map[string]*Tree
+	subtrees     []*Tree
+	staticLeaves map[string]*Leaf
+	leaves       []*Leaf
+
+	// routes maps canonical forms of patterns added to the root tree to their routes,
+	// which is used to detect conflicts.
+	routes map[string]treeRoute
+}
 
-	subtrees []*Tree
-	leaves   []*Leaf
+// treeRoute is a route added to the tree, it is implicit when added for an optional segment.
+type treeRoute struct {
+	pattern  string
+	implicit bool
 }
 
 func NewSubtree(parent *Tree, pattern string) *Tree {
 	typ, rawPattern, wildcards, reg := checkPattern(pattern)
-	return &Tree{parent, typ, pattern, rawPattern, wildcards, reg, make([]*Tree, 0, 5), make([]*Leaf, 0, 5)}
+	t := &Tree{
+		parent:     parent,
+		typ:        typ,
+		pattern:    pattern,
+		rawPattern: rawPattern,
+		wildcards:  wildcards,
+		reg:        reg,
+		class:      charClassOf(reg),
+	}
+	t.converters, _ = paramConverters(pattern)
+	if typ == _PATTERN_STATIC {
+		t.segments = []string{pattern}
+	}
+	return t
 }
 
 func NewTree() *Tree {
 	return NewSubtree(nil, "")
 }
 
-func (t *Tree) addLeaf(pattern string, handle Handle) *Leaf {
-	for i := 0; i < len(t.leaves); i++ {
-		if t.leaves[i].pattern == pattern {
-			return t.leaves[i]
+// setSegments sets the compressed static segments of the subtree.
+func (t *Tree) setSegments(segments []string) {
+	t.segments = segments
+	t.pattern = strings.Join(segments, "/")
+	t.rawPattern = t.pattern
+}
+
+// splitPattern splits the pattern into segments, extra slashes between segments are ignored.
+func splitPattern(pattern string) []string {
+	var segments []string
+	for {
+		pattern = strings.TrimPrefix(pattern, "/")
+		i := strings.IndexByte(pattern, '/')
+		if i == -1 {
+			return append(segments, pattern)
 		}
+		segments = append(segments, pattern[:i])
+		pattern = pattern[i+1:]
 	}
+}
 
-	leaf := NewLeaf(t, pattern, handle)
+// canonicalSegment returns the form of the segment that is the same for segments matching
+// the same paths, e.g. ":id" and ":name".
+func canonicalSegment(segment string) string {
+	typ, _, _, reg := checkPattern(segment)
+	switch typ {
+	case _PATTERN_REGEXP:
+		return ":" + reg.String()
+	case _PATTERN_HOLDER:
+		return ":"
+	case _PATTERN_STATIC:
+		return segment
+	default:
+		return strings.TrimLeft(segment, "?")
+	}
+}
 
-	// Add exact same leaf to grandparent/parent level without optional.
-	if leaf.optional {
-		parent := leaf.parent
-		if parent.parent != nil {
-			parent.parent.addLeaf(parent.pattern, handle)
-		} else {
-			parent.addLeaf("", handle) // Root tree can add as empty pattern.
+func (t *Tree) addLeaf(segment, route string, handle Handle) (*Leaf, error) {
+	if leaf := t.staticLeaves[segment]; leaf != nil {
+		return leaf, nil
+	}
+	for _, leaf := range t.leaves {
+		if leaf.pattern == segment {
+			return leaf, nil
+		}
+	}
+
+	leaf := NewLeaf(t, segment, handle)
+	leaf.route = route
+	if leaf.typ == _PATTERN_STATIC {
+		if t.staticLeaves == nil {
+			t.staticLeaves = make(map[string]*Leaf)
+		}
+		t.staticLeaves[segment] = leaf
+		return leaf, nil
+	}
+
+	// A "*.*" matches every single segment before a holder does.
+	for _, l := range t.leaves {
+		if l.typ == _PATTERN_PATH_EXT && leaf.typ == _PATTERN_HOLDER {
+			return nil, fmt.Errorf("route %q conflicts with existing route %q: it is shadowed by the existing route", route, l.route)
+		} else if l.typ == _PATTERN_HOLDER && leaf.typ == _PATTERN_PATH_EXT {
+			return nil, fmt.Errorf("route %q conflicts with existing route %q: it shadows the existing route", route, l.route)
 		}
 	}
 
@@ -211,19 +532,58 @@ func (t *Tree) addLeaf(pattern string, handle Handle) *Leaf {
 			break
 		}
 	}
+	t.leaves = append(t.leaves[:i], append([]*Leaf{leaf}, t.leaves[i:]...)...)
+	return leaf, nil
+}
 
-	if i == len(t.leaves) {
-		t.leaves = append(t.leaves, leaf)
-	} else {
-		t.leaves = append(t.leaves[:i], append([]*Leaf{leaf}, t.leaves[i:]...)...)
+// addStatic adds the static segments to the tree, it returns the subtree of the last added
+// segment and the number of added segments.
+func (t *Tree) addStatic(segments []string) (*Tree, int) {
+	subtree := t.statics[segments[0]]
+	if subtree == nil {
+		subtree = NewSubtree(t, segments[0])
+		subtree.setSegments(segments)
+		if t.statics == nil {
+			t.statics = make(map[string]*Tree)
+		}
+		t.statics[segments[0]] = subtree
+		return subtree, len(segments)
 	}
-	return leaf
+
+	// Split the subtree where the segments diverge.
+	i := 1
+	for i < len(segments) && i < len(subtree.segments) && subtree.segments[i] == segments[i] {
+		i++
+	}
+	if i < len(subtree.segments) {
+		upper := NewSubtree(t, segments[0])
+		upper.setSegments(subtree.segments[:i:i])
+		upper.statics = map[string]*Tree{subtree.segments[i]: subtree}
+		subtree.setSegments(subtree.segments[i:])
+		subtree.parent = upper
+		t.statics[segments[0]] = upper
+		subtree = upper
+	}
+	return subtree, i
 }
 
-func (t *Tree) addSubtree(segment, pattern string, handle Handle) *Leaf {
-	for i := 0; i < len(t.subtrees); i++ {
-		if t.subtrees[i].pattern == segment {
-			return t.subtrees[i].addNextSegment(pattern, handle)
+// checkPatternType returns the type of the pattern.
+func checkPatternType(pattern string) patternType {
+	switch pattern = strings.TrimLeft(pattern, "?"); {
+	case pattern == "*":
+		return _PATTERN_MATCH_ALL
+	case pattern == "*.*":
+		return _PATTERN_PATH_EXT
+	case strings.Contains(pattern, ":"):
+		return _PATTERN_REGEXP
+	}
+	return _PATTERN_STATIC
+}
+
+func (t *Tree) addSubtree(segment string) *Tree {
+	for _, subtree := range t.subtrees {
+		if subtree.pattern == segment {
+			return subtree
 		}
 	}
 
@@ -234,106 +594,155 @@ func (t *Tree) addSubtree(segment, pattern string, handle Handle) *Leaf {
 			break
 		}
 	}
+	t.subtrees = append(t.subtrees[:i], append([]*Tree{subtree}, t.subtrees[i:]...)...)
+	return subtree
+}
 
-	if i == len(t.subtrees) {
-		t.subtrees = append(t.subtrees, subtree)
-	} else {
-		t.subtrees = append(t.subtrees[:i], append([]*Tree{subtree}, t.subtrees[i:]...)...)
+// addSegments adds the route of given segments to the tree.
+func (t *Tree) addSegments(segments []string, route string, handle Handle) (*Leaf, error) {
+	for len(segments) > 1 {
+		if checkPatternType(segments[0]) == _PATTERN_STATIC {
+			// The last segment is always left for the leaf.
+			n := 1
+			for n < len(segments)-1 && checkPatternType(segments[n]) == _PATTERN_STATIC {
+				n++
+			}
+			t, n = t.addStatic(segments[:n:n])
+			segments = segments[n:]
+		} else {
+			t = t.addSubtree(segments[0])
+			segments = segments[1:]
+		}
 	}
-	return subtree.addNextSegment(pattern, handle)
+	return t.addLeaf(segments[0], route, handle)
 }
 
-func (t *Tree) addNextSegment(pattern string, handle Handle) *Leaf {
-	pattern = strings.TrimPrefix(pattern, "/")
+// add adds the route of given segments to the root tree, it returns an error if the route
+// conflicts with an existing one.
+func (t *Tree) add(segments []string, route string, implicit bool, handle Handle) (*Leaf, error) {
+	canonical := make([]string, len(segments))
+	for i := range segments {
+		canonical[i] = canonicalSegment(segments[i])
+	}
+	key := strings.Join(canonical, "/")
+	if existing, ok := t.routes[key]; ok {
+		if implicit {
+			return nil, nil
+		} else if existing.pattern != route {
+			return nil, fmt.Errorf("route %q conflicts with existing route %q: they match the same paths", route, existing.pattern)
+		}
+	} else {
+		if t.routes == nil {
+			t.routes = make(map[string]treeRoute)
+		}
+		t.routes[key] = treeRoute{route, implicit}
+	}
 
-	i := strings.Index(pattern, "/")
-	if i == -1 {
-		return t.addLeaf(pattern, handle)
+	// Add exact same route without the optional segment.
+	if n := len(segments) - 1; len(segments[n]) > 0 && segments[n][0] == '?' {
+		parent := segments[:n:n]
+		if len(parent) == 0 {
+			parent = []string{""} // Root tree can add as empty pattern.
+		}
+		if _, err := t.add(parent, route, true, handle); err != nil {
+			return nil, err
+		}
 	}
-	return t.addSubtree(pattern[:i], pattern[i+1:], handle)
+	return t.addSegments(segments, route, handle)
 }
 
-func (t *Tree) Add(pattern string, handle Handle) *Leaf {
-	pattern = strings.TrimSuffix(pattern, "/")
-	return t.addNextSegment(pattern, handle)
+// addPattern adds the route of given pattern to the tree and returns its leaf, or an error if the
+// route conflicts with an existing one, i.e. they match the same paths, or one shadows the other.
+// The leaf of the existing route is returned when the same pattern is added again.
+func (t *Tree) addPattern(pattern string, handle Handle) (*Leaf, error) {
+	return t.add(splitPattern(strings.TrimSuffix(pattern, "/")), pattern, false, handle)
 }
 
-func (t *Tree) matchLeaf(globLevel int, url string, params Params) (Handle, bool) {
-	url, err := gourl.PathUnescape(url)
+// Add adds the route of given pattern to the tree and returns its leaf.
+// It panics if the route conflicts with an existing one.
+func (t *Tree) Add(pattern string, handle Handle) *Leaf {
+	leaf, err := t.addPattern(pattern, handle)
 	if err != nil {
+		panic(err.Error())
+	}
+	return leaf
+}
+
+func (t *Tree) matchLeaf(globLevel int, url string, params *Params) (Handle, bool) {
+	url, ok := unescapePath(url)
+	if !ok {
 		return nil, false
 	}
-	for i := 0; i < len(t.leaves); i++ {
-		switch t.leaves[i].typ {
-		case _PATTERN_STATIC:
-			if t.leaves[i].pattern == url {
-				return t.leaves[i].handle, true
-			}
+	if leaf := t.staticLeaves[url]; leaf != nil {
+		return leaf.handle, true
+	}
+	for _, leaf := range t.leaves {
+		switch leaf.typ {
 		case _PATTERN_REGEXP:
-			results := t.leaves[i].reg.FindStringSubmatch(url)
-			// Number of results and wildcasrd should be exact same.
-			if len(results)-1 != len(t.leaves[i].wildcards) {
-				break
+			if matchRegexp(leaf.reg, leaf.class, leaf.wildcards, url, params) && convertParams(leaf.converters, *params) {
+				return leaf.handle, true
 			}
-
-			for j := 0; j < len(t.leaves[i].wildcards); j++ {
-				params[t.leaves[i].wildcards[j]] = results[j+1]
-			}
-			return t.leaves[i].handle, true
 		case _PATTERN_PATH_EXT:
-			j := strings.LastIndex(url, ".")
-			if j > -1 {
-				params[":path"] = url[:j]
-				params[":ext"] = url[j+1:]
-			} else {
-				params[":path"] = url
-			}
-			return t.leaves[i].handle, true
+			setPathExt(url, params)
+			return leaf.handle, true
 		case _PATTERN_HOLDER:
-			params[t.leaves[i].wildcards[0]] = url
-			return t.leaves[i].handle, true
+			setParam(params, leaf.wildcards[0], url)
+			return leaf.handle, true
 		case _PATTERN_MATCH_ALL:
-			params["*"] = url
-			params["*"+com.ToStr(globLevel)] = url
-			return t.leaves[i].handle, true
+			setMatchAll(globLevel, url, params)
+			return leaf.handle, true
 		}
 	}
 	return nil, false
 }
 
-func (t *Tree) matchSubtree(globLevel int, segment, url string, params Params) (Handle, bool) {
-	unescapedSegment, err := gourl.PathUnescape(segment)
-	if err != nil {
+// matchStatic matches rest compressed segments of the static subtree, and returns the rest of url.
+func (t *Tree) matchStatic(url string) (string, bool) {
+	for _, segment := range t.segments[1:] {
+		i := strings.IndexByte(url, '/')
+		if i == -1 {
+			return "", false
+		}
+		if unescaped, ok := unescapePath(url[:i]); !ok || unescaped != segment {
+			return "", false
+		}
+		url = url[i+1:]
+	}
+	return url, true
+}
+
+func (t *Tree) matchSubtree(globLevel int, segment, url string, params *Params) (Handle, bool) {
+	unescapedSegment, ok := unescapePath(segment)
+	if !ok {
 		return nil, false
 	}
-	for i := 0; i < len(t.subtrees); i++ {
-		switch t.subtrees[i].typ {
-		case _PATTERN_STATIC:
-			if t.subtrees[i].pattern == unescapedSegment {
-				if handle, ok := t.subtrees[i].matchNextSegment(globLevel, url, params); ok {
-					return handle, true
-				}
+
+	if subtree := t.statics[unescapedSegment]; subtree != nil {
+		if rest, ok := subtree.matchStatic(url); ok {
+			if handle, ok := subtree.matchNextSegment(globLevel, rest, params); ok {
+				return handle, true
 			}
+		}
+	}
+
+	for _, subtree := range t.subtrees {
+		switch subtree.typ {
 		case _PATTERN_REGEXP:
-			results := t.subtrees[i].reg.FindStringSubmatch(unescapedSegment)
-			if len(results)-1 != len(t.subtrees[i].wildcards) {
+			if !matchRegexp(subtree.reg, subtree.class, subtree.wildcards, unescapedSegment, params) ||
+				!convertParams(subtree.converters, *params) {
 				break
 			}
-
-			for j := 0; j < len(t.subtrees[i].wildcards); j++ {
-				params[t.subtrees[i].wildcards[j]] = results[j+1]
-			}
-			if handle, ok := t.subtrees[i].matchNextSegment(globLevel, url, params); ok {
+			if handle, ok := subtree.matchNextSegment(globLevel, url, params); ok {
 				return handle, true
 			}
 		case _PATTERN_HOLDER:
-			if handle, ok := t.subtrees[i].matchNextSegment(globLevel+1, url, params); ok {
-				params[t.subtrees[i].wildcards[0]] = unescapedSegment
+			if handle, ok := subtree.matchNextSegment(globLevel+1, url, params); ok {
+				setParam(params, subtree.wildcards[0], unescapedSegment)
 				return handle, true
 			}
 		case _PATTERN_MATCH_ALL:
-			if handle, ok := t.subtrees[i].matchNextSegment(globLevel+1, url, params); ok {
-				params["*"+com.ToStr(globLevel)] = unescapedSegment
+			if handle, ok := subtree.matchNextSegment(globLevel+1, url, params); ok {
+				setParam(params, "*"+strconv.Itoa(globLevel), unescapedSegment)
 				return handle, true
 			}
 		}
@@ -341,42 +750,38 @@ func (t *Tree) matchSubtree(globLevel int, segment, url string, params Params) (
 
 	if len(t.leaves) > 0 {
 		leaf := t.leaves[len(t.leaves)-1]
-		unescapedURL, err := gourl.PathUnescape(segment + "/" + url)
-		if err != nil {
-			return nil, false
-		}
 		switch leaf.typ {
-		case _PATTERN_PATH_EXT:
-			j := strings.LastIndex(unescapedURL, ".")
-			if j > -1 {
-				params[":path"] = unescapedURL[:j]
-				params[":ext"] = unescapedURL[j+1:]
+		case _PATTERN_PATH_EXT, _PATTERN_MATCH_ALL:
+			unescapedURL, ok := unescapePath(segment + "/" + url)
+			if !ok {
+				return nil, false
+			}
+			if leaf.typ == _PATTERN_PATH_EXT {
+				setPathExt(unescapedURL, params)
 			} else {
-				params[":path"] = unescapedURL
+				setMatchAll(globLevel, unescapedURL, params)
 			}
 			return leaf.handle, true
-		case _PATTERN_MATCH_ALL:
-			params["*"] = unescapedURL
-			params["*"+com.ToStr(globLevel)] = unescapedURL
-			return leaf.handle, true
 		}
 	}
 	return nil, false
 }
 
-func (t *Tree) matchNextSegment(globLevel int, url string, params Params) (Handle, bool) {
-	i := strings.Index(url, "/")
+func (t *Tree) matchNextSegment(globLevel int, url string, params *Params) (Handle, bool) {
+	i := strings.IndexByte(url, '/')
 	if i == -1 {
 		return t.matchLeaf(globLevel, url, params)
 	}
 	return t.matchSubtree(globLevel, url[:i], url[i+1:], params)
 }
 
+// Match returns the handle and params of the route matching given URL path. Params are nil
+// when the route has no wildcard.
 func (t *Tree) Match(url string) (Handle, Params, bool) {
 	url = strings.TrimPrefix(url, "/")
 	url = strings.TrimSuffix(url, "/")
-	params := make(Params)
-	handle, ok := t.matchNextSegment(0, url, params)
+	var params Params
+	handle, ok := t.matchNextSegment(0, url, &params)
 	return handle, params, ok
 }
 
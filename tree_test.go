@@ -15,6 +15,7 @@
 package macaron
 
 import (
+	"strconv"
 	"strings"
 	"testing"
 
@@ -34,6 +35,8 @@ func Test_getWildcards(t *testing.T) {
 		":id([0-9]+)_:name":                 {"([0-9]+)_(.+)", ":id :name"},
 		"article_:id_:page.html":            {"article_(.+)_(.+).html", ":id :page"},
 		"article_:id:int_:page:string.html": {"article_([0-9]+)_([\\w]+).html", ":id :page"},
+		":id:slug/:page":                    {"([a-z0-9]+(?:-[a-z0-9]+)*)/(.+)", ":id :page"},
+		":day:date_:name([\\w:]+)":          {"([0-9]{4}-[0-9]{2}-[0-9]{2})_([\\w:]+)", ":day :name"},
 		"*":                                 {"*", ""},
 		"*.*":                               {"*.*", ""},
 	}
@@ -56,6 +59,7 @@ func Test_getRawPattern(t *testing.T) {
 		"article_:id_:page.html":                 "article_:id_:page.html",
 		"article_:id:int_:page:string.html":      "article_:id_:page.html",
 		"article_:id([0-9]+)_:page([\\w]+).html": "article_:id_:page.html",
+		":id:uuid/:name:unknown":                 ":id/:name:unknown",
 		"*":                                      "*",
 		"*.*":                                    "*.*",
 	}
@@ -241,3 +245,177 @@ func Test_Tree_Match(t *testing.T) {
 		})
 	})
 }
+
+func Test_ParamType(t *testing.T) {
+	Convey("Match routes with param types", t, func() {
+		AddParamType("version", ParamType{Pattern: `v[0-9]+`})
+
+		t := NewTree()
+		So(t.Add("/api/:v:version/users/:id:uuid", nil), ShouldNotBeNil)
+		So(t.Add("/posts/:slug:slug", nil), ShouldNotBeNil)
+		So(t.Add("/blobs/:sum:hex", nil), ShouldNotBeNil)
+		So(t.Add("/days/:day:date.html", nil), ShouldNotBeNil)
+		So(t.Add("/ids/:id:int", nil), ShouldNotBeNil)
+
+		cases := []struct {
+			url    string
+			params map[string]string
+			ok     bool
+		}{
+			{"/api/v1/users/0f8fad5b-d9cb-469f-a165-70867728950e", map[string]string{":v": "v1", ":id": "0f8fad5b-d9cb-469f-a165-70867728950e"}, true},
+			{"/api/1/users/0f8fad5b-d9cb-469f-a165-70867728950e", nil, false},
+			{"/api/v1/users/1", nil, false},
+			{"/posts/hello-world", map[string]string{":slug": "hello-world"}, true},
+			{"/posts/--", nil, false},
+			{"/blobs/deadBEEF", map[string]string{":sum": "deadBEEF"}, true},
+			{"/blobs/xyz", nil, false},
+			{"/api/v1/users/junk-0f8fad5b-d9cb-469f-a165-70867728950e-junk", nil, false},
+			{"/api/xv1/users/0f8fad5b-d9cb-469f-a165-70867728950e", nil, false},
+			{"/days/2024-01-02.html", map[string]string{":day": "2024-01-02"}, true},
+			{"/days/x2024-01-02999.html", nil, false},
+			{"/days/2024-13-45.html", nil, false},
+			{"/ids/id42", map[string]string{":id": "42"}, true},
+			{"/ids/99999999999999999999", nil, false},
+		}
+		for _, c := range cases {
+			_, params, ok := t.Match(c.url)
+			So(ok, ShouldEqual, c.ok)
+			if c.ok {
+				So(params, ShouldResemble, Params(c.params))
+			}
+		}
+	})
+
+	Convey("Register invalid param types", t, func() {
+		So(func() { AddParamType("", ParamType{Pattern: `.+`}) }, ShouldPanic)
+		So(func() { AddParamType("bad name", ParamType{Pattern: `.+`}) }, ShouldPanic)
+		So(func() { AddParamType("bad", ParamType{Pattern: `(`}) }, ShouldPanic)
+		So(func() { AddParamType("bad", ParamType{Pattern: `(a|b)`}) }, ShouldPanic)
+	})
+}
+
+func Test_Tree_Compress(t *testing.T) {
+	Convey("Split compressed static subtrees", t, func() {
+		t := NewTree()
+		leaf := t.Add("/api/v1/users/:id", nil)
+		So(t.statics["api"].segments, ShouldResemble, []string{"api", "v1", "users"})
+		So(leaf.URLPath(":id", "1"), ShouldEqual, "/api/v1/users/1")
+
+		t.Add("/api/v2/users", nil)
+		t.Add("/api/v1", nil)
+		So(t.statics["api"].segments, ShouldResemble, []string{"api"})
+		So(t.statics["api"].statics["v1"].segments, ShouldResemble, []string{"v1", "users"})
+		So(leaf.URLPath(":id", "2"), ShouldEqual, "/api/v1/users/2")
+
+		for _, url := range []string{"/api/v1/users/1", "/api/v2/users", "/api/v1", "/api/%76%31/users/1"} {
+			_, _, ok := t.Match(url)
+			So(ok, ShouldBeTrue)
+		}
+		for _, url := range []string{"/api", "/api/v1/users", "/api/v2", "/api/v3/users/1"} {
+			_, _, ok := t.Match(url)
+			So(ok, ShouldBeFalse)
+		}
+	})
+}
+
+func Test_Tree_Conflict(t *testing.T) {
+	Convey("Detect conflicting routes", t, func() {
+		cases := []struct {
+			existing, pattern, err string
+		}{
+			{"/user/:id", "/user/:name", `route "/user/:name" conflicts with existing route "/user/:id": they match the same paths`},
+			{"/user/:id:int", "/user/:uid([0-9]+)", `route "/user/:uid([0-9]+)" conflicts with existing route "/user/:id:int": they match the same paths`},
+			{"/:org/:repo/issues", "/:user/:name/issues", `route "/:user/:name/issues" conflicts with existing route "/:org/:repo/issues": they match the same paths`},
+			{"/user/?:name", "/user", `route "/user" conflicts with existing route "/user/?:name": they match the same paths`},
+			{"/user/?:name", "/user/:id", `route "/user/:id" conflicts with existing route "/user/?:name": they match the same paths`},
+			{"/docs/*.*", "/docs/:name", `route "/docs/:name" conflicts with existing route "/docs/*.*": it is shadowed by the existing route`},
+			{"/docs/:name", "/docs/*.*", `route "/docs/*.*" conflicts with existing route "/docs/:name": it shadows the existing route`},
+		}
+		for _, c := range cases {
+			t := NewTree()
+			t.Add(c.existing, nil)
+			_, err := t.addPattern(c.pattern, nil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, c.err)
+		}
+	})
+
+	Convey("Allow routes that do not conflict", t, func() {
+		t := NewTree()
+		for _, pattern := range []string{
+			"/user/:id",
+			"/user/:id",
+			"/user/new",
+			"/user/:id:int/edit",
+			"/user/:name/profile",
+			"/user",
+			"/org/?:name",
+			"/org/?:id:int",
+			"/*",
+			"/*.*",
+		} {
+			_, err := t.addPattern(pattern, nil)
+			So(err, ShouldBeNil)
+		}
+	})
+
+	Convey("Report conflicting routes with method in router", t, func() {
+		m := New()
+		m.Get("/user/:id", func() {})
+		So(func() { m.Post("/user/:name", func() {}) }, ShouldNotPanic)
+		defer func() {
+			So(recover(), ShouldEqual, `GET route "/user/:name" conflicts with existing route "/user/:id": they match the same paths`)
+		}()
+		m.Get("/user/:name", func() {})
+	})
+}
+
+// benchmarkRoutes returns a large set of routes of both static and dynamic patterns.
+func benchmarkRoutes() []string {
+	routes := make([]string, 0, 400)
+	for i := 0; i < 50; i++ {
+		prefix := "/api/v" + strconv.Itoa(i%3) + "/resource" + strconv.Itoa(i)
+		routes = append(routes,
+			prefix,
+			prefix+"/:id:int",
+			prefix+"/:id:int/edit",
+			prefix+"/:id/comments/:comment",
+			prefix+"/search/:query([a-z]+)",
+			prefix+"/files/*",
+			prefix+"/static/list",
+		)
+	}
+	return routes
+}
+
+func benchmarkTreeMatch(b *testing.B, urls ...string) {
+	t := NewTree()
+	for _, route := range benchmarkRoutes() {
+		t.Add(route, nil)
+	}
+	for _, url := range urls {
+		if _, _, ok := t.Match(url); !ok {
+			b.Fatalf("%q is not matched", url)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, url := range urls {
+			t.Match(url)
+		}
+	}
+}
+
+func Benchmark_Tree_MatchStatic(b *testing.B) {
+	benchmarkTreeMatch(b, "/api/v1/resource49", "/api/v0/resource48/static/list")
+}
+
+func Benchmark_Tree_MatchParams(b *testing.B) {
+	benchmarkTreeMatch(b, "/api/v1/resource49/123/edit", "/api/v0/resource48/abc/comments/1", "/api/v2/resource47/search/go")
+}
+
+func Benchmark_Tree_MatchAll(b *testing.B) {
+	benchmarkTreeMatch(b, "/api/v1/resource49/files/a/b/c.txt")
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// bindingStatus returns the status to respond with for given binding errors.
+func bindingStatus(errs BindingErrors) int {
+	switch {
+	case errs.Has(ERR_CONTENT_TYPE):
+		return http.StatusUnsupportedMediaType
+	case errs.Has(ERR_DESERIALIZATION):
+		return http.StatusBadRequest
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// Typed returns a handler that calls h with request data bound into a new Req, and encodes
+// the returned Resp to the response. Unlike other handlers, h is called directly without
+// dependency injection, so its signature is checked at compile time, e.g.:
+//
+//	m.Post("/users", macaron.Typed(func(ctx *macaron.Context, in CreateUser) (*User, error) {
+//		...
+//	}))
+//
+// Req must be a struct, which is bound and validated like macaron.Bind does. Binding errors
+// are passed to the InternalServerError handler as HTTPError with status 415, 400 or 422 and
+// the BindingErrors as details, so is the error returned by h. Strings and byte slices are
+// written as is, io.Reader is streamed, and other values are encoded by Render in the media
+// type negotiated by the Accept header, or in JSON if Render is not registered. Nothing is
+// written if h has written the response itself or the result is nil.
+func Typed[Req, Resp any](h func(ctx *Context, in Req) (Resp, error)) Handler {
+	if reflect.TypeOf((*Req)(nil)).Elem().Kind() != reflect.Struct {
+		panic("typed handler input must be a struct")
+	}
+
+	return ContextInvoker(func(ctx *Context) {
+		var in Req
+		if errs := ctx.Bind(&in); len(errs) > 0 {
+			ctx.internalServerError(ctx, NewHTTPError(bindingStatus(errs)).WithDetails(errs).Wrap(errs))
+			return
+		}
+
+		out, err := h(ctx, in)
+		if err != nil {
+			ctx.internalServerError(ctx, err)
+			return
+		} else if ctx.Written() {
+			return
+		}
+
+		// Typed nil pointers are not nil as interface values.
+		if rv := reflect.ValueOf(out); !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+			return
+		}
+
+		switch v := any(out).(type) {
+		case string:
+			_, _ = ctx.Resp.Write([]byte(v))
+		case []byte:
+			_, _ = ctx.Resp.Write(v)
+		case io.Reader:
+			_, _ = io.Copy(ctx.Resp, v)
+			if c, ok := v.(io.Closer); ok {
+				_ = c.Close()
+			}
+		default:
+			writeEncoded(ctx, ctx.Resp, http.StatusOK, v)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type typedGreeting struct {
+	Name string `form:"name" json:"name" binding:"Required"`
+}
+
+type typedReply struct {
+	Message string `json:"message"`
+}
+
+func Test_Typed(t *testing.T) {
+	request := func(m *Macaron, method, url, contentType, body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		So(err, ShouldBeNil)
+		if len(contentType) > 0 {
+			req.Header.Set(_CONTENT_TYPE, contentType)
+		}
+		m.ServeHTTP(resp, req)
+		return resp
+	}
+
+	Convey("Bind input and encode output", t, func() {
+		m := New()
+		m.Post("/", Typed(func(ctx *Context, in typedGreeting) (*typedReply, error) {
+			if in.Name == "nobody" {
+				return nil, NewHTTPError(http.StatusNotFound)
+			}
+			return &typedReply{Message: "hello " + in.Name}, nil
+		}))
+
+		resp := request(m, "POST", "/", _CONTENT_JSON, `{"name":"macaron"}`)
+		So(resp.Code, ShouldEqual, http.StatusOK)
+		So(resp.Header().Get(_CONTENT_TYPE), ShouldStartWith, _CONTENT_JSON)
+		So(resp.Body.String(), ShouldEqual, `{"message":"hello macaron"}`)
+
+		resp = request(m, "POST", "/?name=form", "", "")
+		So(resp.Body.String(), ShouldEqual, `{"message":"hello form"}`)
+
+		resp = request(m, "POST", "/", _CONTENT_JSON, `{"name":"nobody"}`)
+		So(resp.Code, ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("Respond with binding errors", t, func() {
+		m := New()
+		m.InternalServerError(ProblemErrorHandler)
+		m.Post("/", Typed(func(ctx *Context, in typedGreeting) (string, error) {
+			return "hello " + in.Name, nil
+		}))
+
+		resp := request(m, "POST", "/", _CONTENT_JSON, `{}`)
+		So(resp.Code, ShouldEqual, http.StatusUnprocessableEntity)
+		So(resp.Body.String(), ShouldContainSubstring, `"details":[{"field":"name","classification":"RequiredError"`)
+
+		resp = request(m, "POST", "/", _CONTENT_JSON, `{`)
+		So(resp.Code, ShouldEqual, http.StatusBadRequest)
+
+		resp = request(m, "POST", "/", "application/x-unknown", "name")
+		So(resp.Code, ShouldEqual, http.StatusUnsupportedMediaType)
+	})
+
+	Convey("Write output of other types", t, func() {
+		m := New()
+		m.Get("/string", Typed(func(ctx *Context, _ struct{}) (string, error) {
+			return "hello", nil
+		}))
+		m.Get("/reader", Typed(func(ctx *Context, _ struct{}) (*strings.Reader, error) {
+			return strings.NewReader("stream"), nil
+		}))
+		m.Get("/written", Typed(func(ctx *Context, _ struct{}) (*typedReply, error) {
+			ctx.Resp.WriteHeader(http.StatusCreated)
+			return &typedReply{}, nil
+		}))
+		m.Get("/nil/reader", Typed(func(ctx *Context, _ struct{}) (*strings.Reader, error) {
+			return nil, nil
+		}))
+		m.Get("/nil/reply", Typed(func(ctx *Context, _ struct{}) (*typedReply, error) {
+			return nil, nil
+		}))
+		m.Get("/error", Typed(func(ctx *Context, _ struct{}) ([]byte, error) {
+			return []byte("ignored"), errors.New("boom")
+		}))
+
+		So(request(m, "GET", "/string", "", "").Body.String(), ShouldEqual, "hello")
+		So(request(m, "GET", "/reader", "", "").Body.String(), ShouldEqual, "stream")
+
+		for _, url := range []string{"/nil/reader", "/nil/reply"} {
+			resp := request(m, "GET", url, "", "")
+			So(resp.Code, ShouldEqual, http.StatusOK)
+			So(resp.Body.String(), ShouldBeBlank)
+		}
+
+		resp := request(m, "GET", "/written", "", "")
+		So(resp.Code, ShouldEqual, http.StatusCreated)
+		So(resp.Body.String(), ShouldBeBlank)
+
+		resp = request(m, "GET", "/error", "", "")
+		So(resp.Code, ShouldEqual, http.StatusInternalServerError)
+		So(resp.Body.String(), ShouldEqual, "boom\n")
+	})
+
+	Convey("Input of non-struct type", t, func() {
+		So(func() {
+			Typed(func(ctx *Context, in string) (string, error) { return in, nil })
+		}, ShouldPanic)
+	})
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// requestTypes are the types of services mapped to every context by Macaron itself.
+var requestTypes = []reflect.Type{
+	reflect.TypeOf((*Context)(nil)),
+	reflect.TypeOf((*http.ResponseWriter)(nil)).Elem(),
+	reflect.TypeOf((*http.Request)(nil)),
+}
+
+// providerHandler is a handler of built-in middleware that maps services to the context, e.g. Renderer
+// and Bind, so that Validate knows the services it provides are available to handlers after it.
+type providerHandler struct {
+	handle   ContextInvoker
+	provided []reflect.Type
+}
+
+// Invoke implements inject.FastInvoker.
+func (h *providerHandler) Invoke(params []interface{}) ([]reflect.Value, error) {
+	return h.handle.Invoke(params)
+}
+
+// Provides returns types of services the handler maps.
+func (h *providerHandler) Provides() []reflect.Type {
+	return h.provided
+}
+
+// handlerFunc returns the function of given handler, which is the wrapped one for providerHandler.
+func handlerFunc(h Handler) Handler {
+	if p, ok := h.(*providerHandler); ok {
+		return p.handle
+	}
+	return h
+}
+
+// typeOfProvided returns the type of given value, or the interface type if it is
+// a pointer to interface.
+func typeOfProvided(value interface{}) reflect.Type {
+	typ := reflect.TypeOf(value)
+	if typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Interface {
+		return typ.Elem()
+	}
+	return typ
+}
+
+// Provide declares types of services that are mapped to the context by middleware at request time,
+// so that Validate knows arguments of these types can be satisfied. Like MapTo, an interface type is
+// declared by a nil pointer to it, e.g. m.Provide((*session.Store)(nil)). Services mapped by built-in
+// middleware like Renderer and Bind do not need to be declared.
+func (m *Macaron) Provide(values ...interface{}) {
+	for _, v := range values {
+		m.provided = append(m.provided, typeOfProvided(v))
+	}
+}
+
+// DependencyError represents an argument of handler that cannot be satisfied by any known service.
+type DependencyError struct {
+	// Route is the method and pattern of the route prefixed with its host pattern and followed by
+	// its constraints, or the kind of handlers that are not registered for routes, e.g. "middleware"
+	// and "NotFound".
+	Route string
+	// Handler is the function name of the handler.
+	Handler string
+	// Type is the type of the argument.
+	Type reflect.Type
+}
+
+func (e DependencyError) Error() string {
+	return fmt.Sprintf("%s: %s: no service is mapped for type %v", e.Route, e.Handler, e.Type)
+}
+
+// DependencyErrors represents all arguments of handlers that cannot be satisfied.
+type DependencyErrors []DependencyError
+
+func (errs DependencyErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i := range errs {
+		msgs[i] = errs[i].Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// provides returns true if an argument of given type can be satisfied by services mapped globally,
+// mapped for every request, declared by Provide, or given extras.
+func (m *Macaron) provides(typ reflect.Type, extras []reflect.Type) bool {
+	if m.GetVal(typ).IsValid() {
+		return true
+	}
+
+	for _, types := range [][]reflect.Type{requestTypes, m.provided, extras} {
+		for _, t := range types {
+			if t == typ || (typ.Kind() == reflect.Interface && t.Implements(typ)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateHandlers appends an error to errs for every argument of given handlers that cannot be satisfied.
+// Services mapped by built-in middleware among the handlers are available to the handlers after it, and
+// types of them are appended to extras which is returned.
+func (m *Macaron) validateHandlers(errs DependencyErrors, route string, handlers []Handler, extras []reflect.Type) (DependencyErrors, []reflect.Type) {
+	for _, h := range handlers {
+		typ := reflect.TypeOf(handlerFunc(h))
+		if typ == nil || typ.Kind() != reflect.Func {
+			continue
+		}
+		for i := 0; i < typ.NumIn(); i++ {
+			if !m.provides(typ.In(i), extras) {
+				errs = append(errs, DependencyError{route, handlerName(h), typ.In(i)})
+			}
+		}
+		if p, ok := h.(*providerHandler); ok {
+			extras = append(extras, p.Provides()...)
+		}
+	}
+	return errs, extras
+}
+
+// Validate checks arguments of all handlers registered by Use, Handle and its shortcuts, Group,
+// NotFound, MethodNotAllowed and InternalServerError, and returns DependencyErrors for arguments that
+// cannot be satisfied by services mapped globally, the ones Macaron maps for every request, or the
+// ones mapped by built-in middleware like Renderer and Bind used before the handler. Services mapped by
+// third-party middleware need to be declared by Provide. It returns nil if all arguments can be satisfied.
+//
+// Run and other serving methods only log these errors as warnings and keep serving, so call Validate
+// before serving to refuse to start with missing dependencies:
+//
+//	if err := m.Validate(); err != nil {
+//		log.Fatal(err)
+//	}
+func (m *Macaron) Validate() error {
+	errs, provided := m.validateHandlers(nil, "middleware", m.handlers, nil)
+	// Routes must not see services provided by each other.
+	provided = slices.Clip(provided)
+	for _, info := range m.routeInfos {
+		errs, _ = m.validateHandlers(errs, info.Method+" "+info.route(), info.handlers, provided)
+	}
+	errs, _ = m.validateHandlers(errs, "NotFound", m.notFoundHandlers, provided)
+	errs, _ = m.validateHandlers(errs, "MethodNotAllowed", m.methodNotAllowedHandlers, provided)
+	errs, _ = m.validateHandlers(errs, "InternalServerError", m.internalServerErrorHandlers, append(provided, errorType))
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// reportDependencies logs all arguments of handlers that cannot be satisfied.
+func (m *Macaron) reportDependencies() {
+	if errs, ok := m.Validate().(DependencyErrors); ok {
+		for _, err := range errs {
+			m.getLogger().Printf("WARNING: %v\n", err)
+		}
+	}
+}
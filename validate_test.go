@@ -0,0 +1,114 @@
+// Copyright 2026 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type validateStore struct{}
+
+type validateForm struct {
+	Name string `form:"name"`
+}
+
+func validateHandler(store *validateStore) string { return "" }
+
+func Test_Validate(t *testing.T) {
+	Convey("Validate satisfied dependencies", t, func() {
+		m := New()
+		m.Use(Renderer())
+		m.Use(func(ctx *Context, w http.ResponseWriter, req *http.Request, r Render) {})
+		m.Map(&validateStore{})
+		m.MapTo(&bytes.Buffer{}, (*io.Writer)(nil))
+		m.Get("/", validateHandler, func(w io.Writer) {})
+		m.Post("/", Bind(validateForm{}), func(form validateForm, errs BindingErrors) {})
+		m.InternalServerError(func(ctx *Context, err error) {})
+		So(m.Validate(), ShouldBeNil)
+	})
+
+	Convey("Built-in middleware declares services it provides", t, func() {
+		h := Bind(&validateForm{})
+		p, ok := h.(interface{ Provides() []reflect.Type })
+		So(ok, ShouldBeTrue)
+		So(p.Provides(), ShouldResemble, []reflect.Type{reflect.TypeOf(&validateForm{}), reflect.TypeOf(BindingErrors(nil))})
+		So(handlerName(h), ShouldEqual, "gopkg.in/macaron.v1.Bind.func1")
+	})
+
+	Convey("Only know services of built-in middleware used by the instance", t, func() {
+		Renderer()
+		m := New()
+		m.Get("/", func(r Render) {})
+		m.Post("/", Bind(validateForm{}), func(form validateForm) {})
+		m.Put("/", func(form validateForm) {})
+		m.Group("/render", func() {
+			m.Get("", func(r Render) {})
+		}, Renderer())
+
+		err := m.Validate()
+		So(err, ShouldNotBeNil)
+		var errs DependencyErrors
+		So(errors.As(err, &errs), ShouldBeTrue)
+		So(errs, ShouldHaveLength, 2)
+		So(errs[0].Route, ShouldEqual, "GET /")
+		So(errs[0].Type, ShouldEqual, reflect.TypeOf((*Render)(nil)).Elem())
+		So(errs[1].Route, ShouldEqual, "PUT /")
+		So(errs[1].Type, ShouldEqual, reflect.TypeOf(validateForm{}))
+	})
+
+	Convey("Report unsatisfied dependencies", t, func() {
+		m := New()
+		m.Use(func(s fmt.Stringer) {})
+		m.Group("/users", func() {
+			m.Get("/:id", validateHandler)
+		}, func(r io.Reader) {})
+		m.NotFound(func(c io.Closer) {})
+
+		err := m.Validate()
+		So(err, ShouldNotBeNil)
+		var errs DependencyErrors
+		So(errors.As(err, &errs), ShouldBeTrue)
+		So(errs, ShouldHaveLength, 4)
+		So(errs[0].Route, ShouldEqual, "middleware")
+		So(errs[0].Type, ShouldEqual, reflect.TypeOf((*fmt.Stringer)(nil)).Elem())
+		So(errs[1].Route, ShouldEqual, "GET /users/:id")
+		So(errs[1].Type, ShouldEqual, reflect.TypeOf((*io.Reader)(nil)).Elem())
+		So(errs[2].Route, ShouldEqual, "GET /users/:id")
+		So(errs[2].Handler, ShouldEqual, "gopkg.in/macaron.v1.validateHandler")
+		So(errs[2].Type, ShouldEqual, reflect.TypeOf(&validateStore{}))
+		So(errs[3].Route, ShouldEqual, "NotFound")
+		So(err.Error(), ShouldContainSubstring, "GET /users/:id: gopkg.in/macaron.v1.validateHandler: no service is mapped for type *macaron.validateStore")
+
+		Convey("Declare services mapped by middleware", func() {
+			m.Provide(&validateStore{}, (*fmt.Stringer)(nil), &bytes.Buffer{}, io.NopCloser(nil))
+			So(m.Validate(), ShouldBeNil)
+		})
+
+		Convey("Report unsatisfied dependencies at startup", func() {
+			var buf bytes.Buffer
+			m.logger.SetOutput(&buf)
+			m.reportDependencies()
+			So(buf.String(), ShouldContainSubstring, "WARNING: NotFound:")
+		})
+	})
+}